@@ -0,0 +1,64 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+// protoc-gen-go-sofabolt is a protoc plugin which generates the BOLT client
+// stubs and server interfaces of the protobuf services.
+//
+//	protoc --go_out=. --go-sofabolt_out=. foo.proto
+//
+// The services are called in the SOFARPC protobuf mode: the unique id defaults to
+// "<full service name>:1.0" and the method name is the lower camel of the rpc name.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const version = "0.0.1"
+
+func main() {
+	if len(os.Args) == 2 && os.Args[1] == "--version" {
+		fmt.Fprintf(os.Stdout, "%v %v\n", filepath.Base(os.Args[0]), version)
+		os.Exit(0)
+	}
+
+	opts, serviceVersion := newOptions()
+	opts.Run(func(gen *protogen.Plugin) error {
+		return generateFiles(gen, *serviceVersion)
+	})
+}
+
+// newOptions returns the options parsing the plugin parameters, e.g.
+// --go-sofabolt_opt=service_version=2.0, and the parsed service version.
+func newOptions() (protogen.Options, *string) {
+	var flags flag.FlagSet
+	serviceVersion := flags.String("service_version", defaultServiceVersion, "version of the service unique id")
+	return protogen.Options{ParamFunc: flags.Set}, serviceVersion
+}
+
+func generateFiles(gen *protogen.Plugin, serviceVersion string) error {
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		if err := generateFile(gen, f, serviceVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
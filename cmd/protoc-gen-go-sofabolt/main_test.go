@@ -0,0 +1,148 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// echoFile is the descriptor of testdata/echo.proto.
+func echoFile() *descriptorpb.FileDescriptorProto {
+	method := func(name, in, out string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(in),
+			OutputType: proto.String(out),
+		}
+	}
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("echo.proto"),
+		Package:    proto.String("sofabolt.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/wrappers.proto"},
+		Options: &descriptorpb.FileOptions{
+			GoPackage: proto.String("github.com/sofastack/sofa-bolt-go/cmd/protoc-gen-go-sofabolt/testdata/echo;echo"),
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("EchoService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Echo", ".google.protobuf.StringValue", ".google.protobuf.StringValue"),
+				method("Count", ".google.protobuf.StringValue", ".google.protobuf.Int64Value"),
+			},
+		}, {
+			Name: proto.String("EmptyService"),
+		}},
+		SourceCodeInfo: &descriptorpb.SourceCodeInfo{
+			Location: []*descriptorpb.SourceCodeInfo_Location{{
+				Path:            []int32{6, 0},
+				Span:            []int32{9, 0, 13, 1},
+				LeadingComments: proto.String(" EchoService echoes the requests.\n"),
+			}, {
+				Path:            []int32{6, 0, 2, 0},
+				Span:            []int32{11, 2, 77},
+				LeadingComments: proto.String(" Echo returns the request.\n"),
+			}},
+		},
+	}
+}
+
+func generate(t *testing.T, files []*descriptorpb.FileDescriptorProto, params string) *pluginpb.CodeGeneratorResponse {
+	names := make([]string, 0, 1)
+	names = append(names, files[len(files)-1].GetName())
+
+	opts, serviceVersion := newOptions()
+	gen, err := opts.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: names,
+		Parameter:      proto.String(params),
+		ProtoFile:      files,
+	})
+	require.Nil(t, err)
+
+	if err := generateFiles(gen, *serviceVersion); err != nil {
+		gen.Error(err)
+	}
+
+	return gen.Response()
+}
+
+func TestGenerateGolden(t *testing.T) {
+	res := generate(t, []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+		echoFile(),
+	}, "paths=source_relative")
+	require.Empty(t, res.GetError())
+	require.Len(t, res.GetFile(), 1)
+	require.Equal(t, "echo_sofabolt.pb.go", res.GetFile()[0].GetName())
+
+	golden := filepath.Join("testdata", "echo_sofabolt.pb.go.golden")
+	content := res.GetFile()[0].GetContent()
+	if *update {
+		require.Nil(t, ioutil.WriteFile(golden, []byte(content), 0644))
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	require.Nil(t, err)
+	require.Equal(t, string(want), content)
+}
+
+func TestGenerateServiceVersion(t *testing.T) {
+	res := generate(t, []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+		echoFile(),
+	}, "paths=source_relative,service_version=2.0")
+	require.Empty(t, res.GetError())
+	require.Len(t, res.GetFile(), 1)
+
+	content := res.GetFile()[0].GetContent()
+	require.Contains(t, content, `const EchoServiceUniqueID = "sofabolt.test.EchoService:2.0"`)
+	require.Contains(t, content, `const EmptyServiceUniqueID = "sofabolt.test.EmptyService:2.0"`)
+	require.NotContains(t, content, ":1.0")
+}
+
+func TestGenerateNoServices(t *testing.T) {
+	f := echoFile()
+	f.Service = nil
+	f.SourceCodeInfo = nil
+
+	res := generate(t, []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+		f,
+	}, "")
+	require.Empty(t, res.GetError())
+	require.Empty(t, res.GetFile())
+}
+
+func TestGenerateStreaming(t *testing.T) {
+	f := echoFile()
+	f.Service[0].Method[0].ServerStreaming = proto.Bool(true)
+
+	res := generate(t, []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+		f,
+	}, "")
+	require.Equal(t, "protoc-gen-go-sofabolt: streaming method sofabolt.test.EchoService.Echo is not supported", res.GetError())
+}
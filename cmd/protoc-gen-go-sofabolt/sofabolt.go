@@ -0,0 +1,156 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package main
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	defaultServiceVersion = "1.0"
+
+	contextPackage  = protogen.GoImportPath("context")
+	sofaboltPackage = protogen.GoImportPath("github.com/sofastack/sofa-bolt-go/sofabolt")
+	protoPackage    = protogen.GoImportPath("google.golang.org/protobuf/proto")
+)
+
+// generateFile generates <name>_sofabolt.pb.go if f has services.
+func generateFile(gen *protogen.Plugin, f *protogen.File, serviceVersion string) error {
+	if len(f.Services) == 0 {
+		return nil
+	}
+
+	for _, service := range f.Services {
+		for _, method := range service.Methods {
+			if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+				return fmt.Errorf("protoc-gen-go-sofabolt: streaming method %s is not supported",
+					method.Desc.FullName())
+			}
+		}
+	}
+
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_sofabolt.pb.go", f.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-sofabolt. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P("// - protoc-gen-go-sofabolt v", version)
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	g.P("package ", f.GoPackageName)
+	g.P()
+
+	for _, service := range f.Services {
+		generateService(g, service, serviceVersion)
+	}
+
+	return nil
+}
+
+func generateService(g *protogen.GeneratedFile, service *protogen.Service, serviceVersion string) {
+	var (
+		uniqueID   = service.GoName + "UniqueID"
+		clientName = service.GoName + "Client"
+		serverName = service.GoName + "Server"
+		clientImpl = unexport(clientName)
+	)
+
+	g.P("// ", uniqueID, " is the default unique id of ", service.Desc.FullName(), ".")
+	g.P("const ", uniqueID, " = ", fmt.Sprintf("%q", string(service.Desc.FullName())+":"+serviceVersion))
+	g.P()
+
+	// Client
+	g.P("// ", clientName, " is the client API for ", service.Desc.FullName(), ".")
+	if service.Comments.Leading != "" {
+		g.P("//")
+	}
+	g.P(service.Comments.Leading, "type ", clientName, " interface {")
+	for _, method := range service.Methods {
+		g.P(method.Comments.Leading, clientSignature(g, method))
+	}
+	g.P("}")
+	g.P()
+
+	g.P("type ", clientImpl, " struct {")
+	g.P("invoker ", sofaboltPackage.Ident("ProtoInvoker"))
+	g.P("uniqueID string")
+	g.P("}")
+	g.P()
+
+	g.P("// New", clientName, " returns the client which invokes the service by invoker, e.g. *sofabolt.Client.")
+	g.P("// uniqueID defaults to ", uniqueID, " if it's empty.")
+	g.P("func New", clientName, "(invoker ", sofaboltPackage.Ident("ProtoInvoker"), ", uniqueID string) ", clientName, " {")
+	g.P("if uniqueID == \"\" {")
+	g.P("uniqueID = ", uniqueID)
+	g.P("}")
+	g.P("return &", clientImpl, "{invoker: invoker, uniqueID: uniqueID}")
+	g.P("}")
+	g.P()
+
+	for _, method := range service.Methods {
+		g.P("func (c *", clientImpl, ") ", clientSignature(g, method), " {")
+		g.P("out := new(", method.Output.GoIdent, ")")
+		g.P("if err := c.invoker.InvokeProto(ctx, c.uniqueID, ", fmt.Sprintf("%q", methodName(method)), ", in, out); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return out, nil")
+		g.P("}")
+		g.P()
+	}
+
+	// Server
+	g.P("// ", serverName, " is the server API for ", service.Desc.FullName(), ".")
+	if service.Comments.Leading != "" {
+		g.P("//")
+	}
+	g.P(service.Comments.Leading, "type ", serverName, " interface {")
+	for _, method := range service.Methods {
+		g.P(method.Comments.Leading, clientSignature(g, method))
+	}
+	g.P("}")
+	g.P()
+
+	g.P("// Register", serverName, " registers srv on r, e.g. *sofabolt.Server.")
+	g.P("// uniqueID defaults to ", uniqueID, " if it's empty.")
+	g.P("func Register", serverName, "(r ", sofaboltPackage.Ident("ProtoRegistrar"), ", uniqueID string, srv ", serverName, ") {")
+	g.P("if uniqueID == \"\" {")
+	g.P("uniqueID = ", uniqueID)
+	g.P("}")
+	for _, method := range service.Methods {
+		g.P("r.HandleProto(uniqueID, ", fmt.Sprintf("%q", methodName(method)), ", new(", method.Input.GoIdent, "),")
+		g.P("func(ctx ", contextPackage.Ident("Context"), ", in ", protoPackage.Ident("Message"), ") (", protoPackage.Ident("Message"), ", error) {")
+		g.P("return srv.", method.GoName, "(ctx, in.(*", method.Input.GoIdent, "))")
+		g.P("})")
+	}
+	g.P("}")
+	g.P()
+}
+
+func clientSignature(g *protogen.GeneratedFile, method *protogen.Method) string {
+	return method.GoName + "(ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context")) +
+		", in *" + g.QualifiedGoIdent(method.Input.GoIdent) + ") (*" +
+		g.QualifiedGoIdent(method.Output.GoIdent) + ", error)"
+}
+
+// methodName returns the method name of SOFARPC which is the java method name.
+func methodName(method *protogen.Method) string {
+	return unexport(string(method.Desc.Name()))
+}
+
+func unexport(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
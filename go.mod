@@ -3,13 +3,16 @@ module github.com/sofastack/sofa-bolt-go
 go 1.14
 
 require (
+	github.com/DataDog/zstd v1.4.5
 	github.com/fatih/color v1.9.0
+	github.com/golang/snappy v0.0.4
 	github.com/jpillora/backoff v1.0.0
-	github.com/json-iterator/go v1.1.10
 	github.com/paulbellamy/ratecounter v0.2.0
+	github.com/prometheus/client_golang v1.7.1
 	github.com/sofastack/sofa-common-go v0.0.1
 	github.com/sofastack/sofa-hessian-go v0.0.1
 	github.com/spf13/cobra v1.0.0
 	github.com/stretchr/testify v1.6.1
 	go.uber.org/atomic v1.6.0
+	google.golang.org/protobuf v1.23.0
 )
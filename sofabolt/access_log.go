@@ -0,0 +1,143 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sofalogger "github.com/sofastack/sofa-common-go/logger"
+)
+
+const (
+	accessLogRedacted = "******"
+)
+
+// AccessLogger writes structured records. *sofalogger.SofaLogger implements it.
+type AccessLogger interface {
+	Info(msg string, fields ...sofalogger.Field)
+}
+
+type AccessLoggerFunc func(msg string, fields ...sofalogger.Field)
+
+func (f AccessLoggerFunc) Info(msg string, fields ...sofalogger.Field) { f(msg, fields...) }
+
+type AccessLogOptions struct {
+	Logger AccessLogger `json:"-"`
+	// Sampling logs 1 of every Sampling successful records, 0 or 1 logs all.
+	// Failed records (error or non-success status) are always logged.
+	Sampling uint32 `json:"sampling"`
+	// Headers logs the request headers if enabled.
+	Headers bool `json:"headers"`
+	// Redact masks the values of these headers. A key ends with "*" matches
+	// all the headers with the prefix.
+	Redact []string `json:"redact"`
+}
+
+type accessLog struct {
+	options  AccessLogOptions
+	records  uint32
+	keys     map[string]struct{}
+	prefixes []string
+}
+
+func newAccessLog(o *AccessLogOptions) *accessLog {
+	if o == nil || o.Logger == nil {
+		return nil
+	}
+
+	al := &accessLog{
+		options: *o,
+		keys:    make(map[string]struct{}, len(o.Redact)),
+	}
+	for _, k := range o.Redact {
+		if strings.HasSuffix(k, "*") {
+			al.prefixes = append(al.prefixes, strings.TrimSuffix(k, "*"))
+		} else {
+			al.keys[k] = struct{}{}
+		}
+	}
+
+	return al
+}
+
+func (al *accessLog) sampled(status Status, err error) bool {
+	if err != nil || status != StatusSuccess || al.options.Sampling <= 1 {
+		return true
+	}
+	return atomic.AddUint32(&al.records, 1)%al.options.Sampling == 1
+}
+
+func (al *accessLog) redacted(k string) bool {
+	if _, ok := al.keys[k]; ok {
+		return true
+	}
+	for _, p := range al.prefixes {
+		if strings.HasPrefix(k, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (al *accessLog) log(side string, conn net.Conn, req *Request, status Status,
+	bytesIn, bytesOut int, latency time.Duration, err error) {
+	if req.GetCMDCode() == CMDCodeBOLTHeartbeat {
+		return
+	}
+
+	if !al.sampled(status, err) {
+		return
+	}
+
+	h := req.GetHeaders()
+	fields := []sofalogger.Field{
+		sofalogger.String("side", side),
+		sofalogger.String("remote", remoteAddr(conn)),
+		sofalogger.Stringer("proto", req.GetProto()),
+		sofalogger.Uint16("cmdcode", uint16(req.GetCMDCode())),
+		sofalogger.Uint32("rid", req.GetRequestID()),
+		sofalogger.String("service", h.Get(headerTargetService)),
+		sofalogger.String("method", h.Get(headerMethodName)),
+		sofalogger.Uint16("status", uint16(status)),
+		sofalogger.Int("bytes_in", bytesIn),
+		sofalogger.Int("bytes_out", bytesOut),
+		sofalogger.Duration("latency", latency),
+	}
+	if err != nil {
+		fields = append(fields, sofalogger.Error(err))
+	}
+	if al.options.Headers {
+		fields = append(fields, sofalogger.Object("headers", accessLogHeaders{al: al, h: h}))
+	}
+
+	al.options.Logger.Info("access", fields...)
+}
+
+type accessLogHeaders struct {
+	al *accessLog
+	h  *SimpleMap
+}
+
+func (a accessLogHeaders) MarshalLogObject(enc sofalogger.ObjectEncoder) error {
+	a.h.Range(func(k, v string) {
+		if a.al.redacted(k) {
+			v = accessLogRedacted
+		}
+		enc.AddString(k, v)
+	})
+	return nil
+}
@@ -0,0 +1,114 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	sofalogger "github.com/sofastack/sofa-common-go/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type accessLogRecorder struct {
+	sync.Mutex
+	records []map[string]sofalogger.Field
+}
+
+func (r *accessLogRecorder) Info(msg string, fields ...sofalogger.Field) {
+	m := make(map[string]sofalogger.Field, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f
+	}
+	r.Lock()
+	r.records = append(r.records, m)
+	r.Unlock()
+}
+
+func (r *accessLogRecorder) get() []map[string]sofalogger.Field {
+	r.Lock()
+	defer r.Unlock()
+	return append([]map[string]sofalogger.Field(nil), r.records...)
+}
+
+func TestAccessLog(t *testing.T) {
+	srvlog := &accessLogRecorder{}
+	clilog := &accessLogRecorder{}
+
+	srv, err := NewServer(
+		WithServerAccessLog(&AccessLogOptions{Logger: srvlog, Headers: true}),
+		WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			if req.GetHeaders().Get("fail") != "" {
+				rw.GetResponse().SetStatus(StatusServerException)
+			}
+			rw.GetResponse().SetContentString("Hello World")
+			rw.Write() // nolint
+		})))
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go srv.ServeConn(p1) // nolint
+
+	c, err := NewClient(
+		WithClientConn(p0),
+		WithClientAccessLog(&AccessLogOptions{Logger: clilog, Sampling: 5}),
+	)
+	require.Nil(t, err)
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		req := AcquireRequest()
+		req.GetHeaders().Set(headerTargetService, "com.alipay.test.Service:1.0")
+		req.GetHeaders().Set(headerMethodName, "echo")
+		if i == 9 {
+			req.GetHeaders().Set("fail", "1")
+		}
+		res := AcquireResponse()
+		require.Nil(t, c.DoTimeout(req, res, time.Second))
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+	}
+
+	require.Eventually(t, func() bool { return len(srvlog.get()) == 10 }, time.Second, time.Millisecond)
+	rec := srvlog.get()[0]
+	require.Equal(t, SideServer, rec["side"].String)
+	require.Equal(t, "pipe", rec["remote"].String)
+	require.Equal(t, "com.alipay.test.Service:1.0", rec["service"].String)
+	require.Equal(t, "echo", rec["method"].String)
+	require.Equal(t, int64(StatusSuccess), rec["status"].Integer)
+	require.True(t, rec["bytes_out"].Integer > 0)
+	require.Contains(t, rec, "headers")
+
+	// 1 of 5 successful records plus the failed one
+	records := clilog.get()
+	require.Len(t, records, 3)
+	require.Equal(t, SideClient, records[2]["side"].String)
+	require.Equal(t, int64(StatusServerException), records[2]["status"].Integer)
+	require.NotContains(t, records[0], "headers")
+}
+
+func TestAccessLogRedact(t *testing.T) {
+	al := newAccessLog(&AccessLogOptions{
+		Logger: AccessLoggerFunc(func(string, ...sofalogger.Field) {}),
+		Redact: []string{"authorization", "rpc_trace_context.*"},
+	})
+	require.True(t, al.redacted("authorization"))
+	require.True(t, al.redacted("rpc_trace_context.sofaTraceId"))
+	require.False(t, al.redacted("sofa_head_target_service"))
+
+	require.Nil(t, newAccessLog(nil))
+	require.Nil(t, newAccessLog(&AccessLogOptions{}))
+}
@@ -0,0 +1,53 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/capconn"
+)
+
+// SplitCommand returns the length of the first command in b, or 0 if b does
+// not hold a complete command yet. It implements capconn.Splitter.
+func SplitCommand(b []byte) (int, error) {
+	var cmd Command
+	n, err := ReadCommand(&ReadOption{}, bytes.NewReader(b), &cmd)
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return n, nil
+}
+
+// FormatCommand decodes data as a command and returns Command.String(), or
+// the hex dump of data if it cannot be decoded.
+func FormatCommand(data []byte) string {
+	var cmd Command
+	if _, err := ReadCommand(&ReadOption{}, bytes.NewReader(data), &cmd); err != nil {
+		return hex.EncodeToString(data)
+	}
+	return cmd.String()
+}
+
+// NewCaptureConn wraps conn to record every command read or written to r.
+func NewCaptureConn(conn net.Conn, r capconn.Recorder) net.Conn {
+	return capconn.New(conn, r, SplitCommand)
+}
@@ -0,0 +1,136 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/capconn"
+	"github.com/stretchr/testify/require"
+)
+
+type lockedBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func TestSplitCommand(t *testing.T) {
+	req := AcquireRequest()
+	defer ReleaseRequest(req)
+	req.SetContentString("hello")
+	req.GetHeaders().Set("service", "echo")
+	b, err := req.Write(&WriteOption{}, nil)
+	require.Nil(t, err)
+
+	for i := 1; i < len(b); i++ {
+		n, err := SplitCommand(b[:i])
+		require.Nil(t, err)
+		require.Equal(t, 0, n)
+	}
+
+	n, err := SplitCommand(append(b, b...))
+	require.Nil(t, err)
+	require.Equal(t, len(b), n)
+
+	_, err = SplitCommand([]byte{0xff, 0, 0})
+	require.NotNil(t, err)
+
+	require.Equal(t, req.String(), FormatCommand(b))
+	require.Equal(t, "ff00", FormatCommand([]byte{0xff, 0}))
+}
+
+func TestCapture(t *testing.T) {
+	var (
+		lock   sync.Mutex
+		srvbuf = &lockedBuffer{}
+		frames []capconn.Frame
+	)
+
+	srv, err := NewServer(
+		WithServerCapture(capconn.NewTextRecorder(srvbuf, FormatCommand)),
+		WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			rw.GetResponse().SetContent(req.GetContent())
+			rw.Write() // nolint
+		})))
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go srv.ServeConn(p1) // nolint
+
+	c, err := NewClient(
+		WithClientConn(p0),
+		WithClientCapture(capconn.RecorderFunc(func(f *capconn.Frame) error {
+			lock.Lock()
+			fc := *f
+			fc.Data = append([]byte(nil), f.Data...)
+			frames = append(frames, fc)
+			lock.Unlock()
+			return nil
+		})),
+	)
+	require.Nil(t, err)
+
+	for _, content := range []string{"hello", "world"} {
+		req := AcquireRequest()
+		req.SetContentString(content)
+		res := AcquireResponse()
+		require.Nil(t, c.DoTimeout(req, res, time.Second))
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+	}
+	c.Close()
+
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(frames) == 4
+	}, time.Second, time.Millisecond)
+
+	lock.Lock()
+	var contents []string
+	for _, f := range frames {
+		if f.Direction != capconn.DirectionInbound {
+			continue
+		}
+		var res Response
+		_, err = res.Read(&ReadOption{}, bytes.NewReader(f.Data))
+		require.Nil(t, err)
+		contents = append(contents, string(res.GetContent()))
+	}
+	lock.Unlock()
+	require.Equal(t, []string{"hello", "world"}, contents)
+
+	require.Eventually(t, func() bool {
+		return strings.Count(srvbuf.String(), "\n") == 4
+	}, time.Second, time.Millisecond)
+	require.Contains(t, srvbuf.String(), " in pipe->pipe len=")
+	require.Contains(t, srvbuf.String(), "Content:68656c6c6f")
+}
@@ -14,6 +14,7 @@
 package sofabolt
 
 import (
+	"context"
 	"errors"
 	"net"
 	"runtime"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/jpillora/backoff"
 	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/asyncwriteconn"
+	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/capconn"
 	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/errorconn"
 	uatomic "go.uber.org/atomic"
 )
@@ -69,16 +71,34 @@ type Client struct {
 		heartbeatTimeout              time.Duration
 		heartbeatProbes               int
 		handler                       Handler
+		handlerAsync                  bool
+		handlerWorkers                int
+		handlerPending                int
+		handlerOrdering               ClientHandlerOrdering
 		dialer                        Dialer
-	}
-
-	rid      uint32
-	connLock sync.RWMutex
-	conn     net.Conn
-	metrics  *ClientMetrics
-	closed   int32
-	rerr     uatomic.Error
-	rerrCh   chan error
+		callbackExecutor              Executor
+		callbackBlockThreshold        time.Duration
+		onCallbackBlocked             func(ictx *InvokeContext, blocked time.Duration)
+		accessLog                     *AccessLogOptions
+		slow                          *SlowOptions
+		capture                       capconn.Recorder
+		compression                   Compression
+		compressionThreshold          int
+		headers                       SimpleMapOptions
+		maxDecompressedSize           int
+		tracer                        ClientTracer
+	}
+
+	rid       uint32
+	connLock  sync.RWMutex
+	conn      net.Conn
+	metrics   *ClientMetrics
+	handlers  *clientHandlerPool
+	accesslog *accessLog
+	closed    int32
+	accepted  int32 // whether the server accepts the compression of the client
+	rerr      uatomic.Error
+	rerrCh    chan error
 }
 
 func NewClient(options ...ClientOptionSetter) (*Client, error) {
@@ -127,7 +147,7 @@ func (c *Client) polyfill() error {
 				err,
 			))
 		} else {
-			c.setConn(conn)
+			c.setConn(c.captureConn(conn))
 		}
 
 	} else {
@@ -139,6 +159,20 @@ func (c *Client) polyfill() error {
 	}
 
 	c.requests = make(map[uint32]*InvokeContext, c.options.maxPendingCommands)
+	c.accesslog = newAccessLog(c.options.accessLog)
+
+	if c.options.handler != nil && c.options.handlerAsync {
+		if c.options.handlerWorkers == 0 {
+			c.options.handlerWorkers = runtime.NumCPU()
+		}
+
+		if c.options.handlerPending == 0 {
+			c.options.handlerPending = c.options.maxPendingCommands
+		}
+
+		c.handlers = newClientHandlerPool(c, c.options.handlerWorkers,
+			c.options.handlerPending, c.options.handlerOrdering)
+	}
 
 	return nil
 }
@@ -178,9 +212,23 @@ func (c *Client) DoCallbackTimeout(req *Request,
 		timeout:  timeout,
 	}
 
-	err := c.invoke(ictx, 0)
+	finish := c.trace(req)
+	if finish != nil && cb != nil {
+		ictx.callback = ClientCallbackerFunc(func(err error, ictx *InvokeContext) {
+			cb.Invoke(err, ictx)
+			finish(ictx.GetResponse(), err)
+		})
+	}
+
+	err := c.invoke(context.Background(), ictx, 0)
+	if err != nil {
+		c.logAccess(ictx, nil, err)
+	}
+	if finish != nil && (err != nil || cb == nil) {
+		finish(nil, err)
+	}
 
-	atomic.StoreInt64(&c.metrics.lasted, time.Now().Unix())
+	c.metrics.SetLasted()
 	atomic.AddInt64(&c.metrics.used, 1)
 	atomic.AddInt64(&c.metrics.references, -1)
 
@@ -192,21 +240,52 @@ func (c *Client) Do(req *Request, res *Response) error {
 }
 
 func (c *Client) DoTimeout(req *Request, res *Response, timeout time.Duration) error {
+	return c.doContext(context.Background(), req, res, timeout)
+}
+
+// doContext is DoTimeout which also gives up waiting the response once ctx is done,
+// and returns ctx.Err() then.
+func (c *Client) doContext(ctx context.Context, req *Request, res *Response, timeout time.Duration) error {
 	atomic.AddInt64(&c.metrics.references, 1)
 	atomic.AddInt64(&c.metrics.used, 1)
 
+	finish := c.trace(req)
 	ictx := c.AcquireInvokeContext(req, res, timeout)
-	err := c.invoke(ictx, timeout)
-	if err != ErrClientTimeout { // let gc handle it if it it's not timeoutd.
+	err := c.invoke(ctx, ictx, timeout)
+	if err != nil {
+		c.logAccess(ictx, nil, err)
+	}
+	if finish != nil {
+		if err != nil {
+			finish(nil, err)
+		} else {
+			finish(res, nil)
+		}
+	}
+	if !isAbandoned(err) { // let gc handle it if it it's not timeoutd.
 		c.ReleaseInvokeContext(ictx)
 	}
 
-	atomic.StoreInt64(&c.metrics.lasted, time.Now().Unix())
+	c.metrics.SetLasted()
 	atomic.AddInt64(&c.metrics.references, -1)
 
 	return err
 }
 
+// trace starts tracing req, it returns nil if there is no tracer. The returned
+// function takes effect once.
+func (c *Client) trace(req *Request) func(res *Response, err error) {
+	if c.options.tracer == nil {
+		return nil
+	}
+
+	var once sync.Once
+	finish := c.options.tracer.TraceClient(c, req)
+	return func(res *Response, err error) {
+		once.Do(func() { finish(res, err) })
+	}
+}
+
 func (c *Client) doheartbeat() {
 	timer := time.NewTimer(c.options.heartbeatInterval)
 	defer timer.Stop()
@@ -252,6 +331,7 @@ func (c *Client) doread() error {
 		res      Response
 		req      Request
 		cmd      Command
+		ro       = NewReadOption().SetHeadersOptions(c.options.headers)
 		crw      = acquireClientResponseWriter(c)
 		ictx     *InvokeContext
 		err      error
@@ -259,6 +339,7 @@ func (c *Client) doread() error {
 		nr       int
 		commands uint64
 	)
+	ro.SetMaxDecompressedSize(c.options.maxDecompressedSize)
 	beforeread := func() error {
 		if cmd.GetProto() > 0 {
 			// Partial command, expect the rest of message arrives in certain timeout.
@@ -273,10 +354,13 @@ func (c *Client) doread() error {
 READLOOP:
 	for {
 		cmd.Reset()
-		if nr, err = cmd.Read(&ReadOption{}, br); err != nil {
+		if nr, err = cmd.Read(ro, br); err != nil {
 			break
 		}
 		atomic.AddInt64(&c.metrics.nread, int64(nr))
+		if compressed, original := ro.GetCompressedSize(); compressed > 0 {
+			c.metrics.ObserveCompression(compressed, original)
+		}
 		commands++
 
 		if cmd.IsRequest() {
@@ -298,8 +382,9 @@ READLOOP:
 	c.Lock()
 	for i := range c.requests {
 		ictx = c.requests[i]
+		c.metrics.addPendingBytes(-int64(ictx.size))
 		if ictx.callback != nil {
-			ictx.callback.Invoke(err, ictx)
+			c.invokeCallback(ictx, err, nil)
 		} else {
 			select { // sanity send
 			case ictx.errCh <- err:
@@ -335,6 +420,10 @@ READLOOP:
 	releaseClientResponseWriter(crw)
 	releaseBufioReader(br)
 
+	if c.handlers != nil {
+		c.handlers.Stop()
+	}
+
 	_ = c.Close()
 	// store and notify the read error
 	c.rerr.Store(err)
@@ -352,7 +441,19 @@ func (c *Client) handleRequest(crw ResponseWriter, req *Request) {
 		return
 	}
 
-	c.options.handler.ServeSofaBOLT(crw, req)
+	if c.handlers == nil {
+		c.options.handler.ServeSofaBOLT(crw, req)
+		return
+	}
+
+	if c.handlers.Serve(req) || req.GetType() == TypeBOLTRequestOneWay {
+		return
+	}
+
+	// reject the request if the handlers are busy
+	crw.GetResponse().SetStatus(StatusServerThreadPoolBusy)
+	// nolint
+	crw.Write()
 }
 
 func (c *Client) handleResponse(res *Response) {
@@ -361,6 +462,17 @@ func (c *Client) handleResponse(res *Response) {
 		// We've not got any pending request. It usually means that
 		// Write partially failed (timeout or request oneway),
 		// and request was already removed;
+		return
+	}
+
+	c.metrics.ObserveRoundTrip(res.GetStatus(), time.Since(ictx.GetCreated()))
+	c.metrics.ObserveResponseSize(res.Size())
+	c.detectCompression(res)
+	c.logAccess(ictx, res, nil)
+	c.detectSlow(ictx, res)
+
+	if ictx.callback != nil {
+		c.invokeCallback(ictx, nil, res)
 	} else {
 		ictx.Invoke(nil, res)
 	}
@@ -368,6 +480,106 @@ func (c *Client) handleResponse(res *Response) {
 	// TODO(detailyang): cleanup stale requests via deadline
 }
 
+// detectCompression enables the compression of the requests once the server
+// accepted it.
+func (c *Client) detectCompression(res *Response) {
+	if c.options.compression == CompressionNone || atomic.LoadInt32(&c.accepted) == 1 {
+		return
+	}
+
+	if acceptsCompression(&res.command, c.options.compression) {
+		atomic.StoreInt32(&c.accepted, 1)
+	}
+}
+
+func (c *Client) logAccess(ictx *InvokeContext, res *Response, err error) {
+	if c.accesslog == nil {
+		return
+	}
+
+	var (
+		status  Status
+		bytesIn int
+	)
+	if res != nil {
+		status = res.GetStatus()
+		bytesIn = res.Size()
+	}
+
+	c.accesslog.log(SideClient, c.GetConn(), ictx.req, status,
+		bytesIn, ictx.size, time.Since(ictx.created), err)
+}
+
+func (c *Client) detectSlow(ictx *InvokeContext, res *Response) {
+	o := c.options.slow
+	if o == nil {
+		return
+	}
+
+	total := time.Since(ictx.created)
+	if total < o.Threshold {
+		return
+	}
+
+	sent := ictx.created
+	if ns := atomic.LoadInt64(&ictx.sent); ns > 0 {
+		sent = time.Unix(0, ns)
+	}
+	sending := time.Duration(atomic.LoadInt64(&ictx.sending))
+
+	sr := &SlowRequest{
+		Side:     SideClient,
+		Remote:   remoteAddr(c.GetConn()),
+		Request:  o.dump(ictx.req),
+		Response: o.dump(res),
+		Queue:    sent.Sub(ictx.created) - sending,
+		Handler:  time.Since(sent),
+		Flush:    sending,
+		Total:    total,
+	}
+	if o.OnSlow != nil {
+		o.OnSlow(sr)
+	}
+}
+
+func (c *Client) invokeCallback(ictx *InvokeContext, err error, res *Response) {
+	if c.options.callbackExecutor == nil {
+		c.doInvokeCallback(ictx, err, res)
+		return
+	}
+
+	// res will be reused by the read loop: hand over an owned copy
+	var owned *Response
+	if res != nil {
+		owned = AcquireResponse()
+		res.CopyTo(owned)
+	}
+
+	c.options.callbackExecutor.Execute(func() {
+		c.doInvokeCallback(ictx, err, owned)
+		if owned != nil {
+			ReleaseResponse(owned)
+		}
+	})
+}
+
+func (c *Client) doInvokeCallback(ictx *InvokeContext, err error, res *Response) {
+	threshold := c.options.callbackBlockThreshold
+	if threshold <= 0 {
+		ictx.Invoke(err, res)
+		return
+	}
+
+	timer := time.AfterFunc(threshold, func() {
+		atomic.AddInt64(&c.metrics.blockedCallbacks, 1)
+		if c.options.onCallbackBlocked != nil {
+			c.options.onCallbackBlocked(ictx, threshold)
+		}
+	})
+	ictx.Invoke(err, res)
+	timer.Stop()
+}
+
 func (c *Client) mayRedial() (net.Conn, error, bool) {
 	if c.Closed() {
 		return nil, nil, false
@@ -414,7 +626,7 @@ func (c *Client) redial() (net.Conn, error) {
 	}
 }
 
-func (c *Client) invoke(ctx *InvokeContext, timeout time.Duration) error {
+func (c *Client) invoke(cctx context.Context, ctx *InvokeContext, timeout time.Duration) error {
 	if atomic.LoadInt32(&c.closed) == 1 {
 		return ErrClientWasClosed
 	}
@@ -436,16 +648,29 @@ func (c *Client) invoke(ctx *InvokeContext, timeout time.Duration) error {
 	var (
 		err error
 		dst = acquireBytes()
+		wo  = NewWriteOption().SetAcceptEncoding(c.options.compression)
 	)
 
-	*dst, err = ctx.req.Write(&WriteOption{}, (*dst)[:0])
+	if atomic.LoadInt32(&c.accepted) == 1 {
+		wo.SetCompression(c.options.compression, c.options.compressionThreshold)
+	}
+
+	*dst, err = ctx.req.Write(wo, (*dst)[:0])
 	if err != nil {
 		releaseBytes(dst)
 		return err
 	}
 
+	ctx.size = len(*dst)
+	c.metrics.ObserveRequestSize(ctx.size)
+	if compressed, original := wo.GetCompressedSize(); compressed > 0 {
+		c.metrics.ObserveCompression(compressed, original)
+	}
 	c.addRequestContext(rid, ctx)
+	start := time.Now()
 	_, err = c.write(*dst)
+	atomic.StoreInt64(&ctx.sending, int64(time.Since(start)))
+	atomic.StoreInt64(&ctx.sent, time.Now().UnixNano())
 	releaseBytes(dst)
 	if err != nil {
 		c.delRequestContext(rid)
@@ -454,6 +679,7 @@ func (c *Client) invoke(ctx *InvokeContext, timeout time.Duration) error {
 
 	if ctx.req.GetType() == TypeBOLTRequestOneWay { // one way
 		c.delRequestContext(rid)
+		c.logAccess(ctx, nil, nil)
 		return nil
 	}
 
@@ -486,17 +712,29 @@ func (c *Client) invoke(ctx *InvokeContext, timeout time.Duration) error {
 			c.delRequestContext(rid)
 
 			return ErrClientTimeout
+
+		case <-cctx.Done():
+			c.delRequestContext(rid)
+
+			return cctx.Err()
 		}
 	}
 
 	return nil
 }
 
+// isAbandoned reports whether the request was given up waiting by err, so it may
+// be still referenced by the read goroutine.
+func isAbandoned(err error) bool {
+	return err == ErrClientTimeout || err == context.Canceled || err == context.DeadlineExceeded
+}
+
 func (c *Client) getAndDelRequestContext(rid uint32) (*InvokeContext, bool) {
 	c.Lock()
 	ictx, ok := c.requests[rid]
 	if ok {
 		delete(c.requests, rid)
+		c.metrics.addPendingBytes(-int64(ictx.size))
 	}
 	c.Unlock()
 	return ictx, ok
@@ -505,13 +743,12 @@ func (c *Client) getAndDelRequestContext(rid uint32) (*InvokeContext, bool) {
 func (c *Client) addRequestContext(rid uint32, ictx *InvokeContext) {
 	c.Lock()
 	c.requests[rid] = ictx
+	c.metrics.addPendingBytes(int64(ictx.size))
 	c.Unlock()
 }
 
 func (c *Client) delRequestContext(rid uint32) {
-	c.Lock()
-	delete(c.requests, rid)
-	c.Unlock()
+	c.getAndDelRequestContext(rid)
 }
 
 func (c *Client) write(d []byte) (int, error) {
@@ -532,7 +769,15 @@ func (c *Client) setConnIdleTimeout(conn net.Conn) error {
 	return conn.SetReadDeadline(zeroTime)
 }
 
+func (c *Client) captureConn(conn net.Conn) net.Conn {
+	if c.options.capture == nil {
+		return conn
+	}
+	return NewCaptureConn(conn, c.options.capture)
+}
+
 func (c *Client) buildAsyncWriteConn(conn net.Conn) (net.Conn, error) {
+	conn = c.captureConn(conn)
 	option := asyncwriteconn.NewOption()
 	option.SetTimeout(c.options.writeTimeout)
 	option.SetFlushInterval(c.options.flushInterval)
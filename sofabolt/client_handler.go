@@ -0,0 +1,153 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"sync"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt/simplemap/fastsimplemap"
+)
+
+// ClientHandlerOrdering controls the order of the inbound requests handled by
+// the asynchronous handler of Client.
+type ClientHandlerOrdering uint8
+
+const (
+	// ClientHandlerUnordered dispatches the requests to any idle worker.
+	ClientHandlerUnordered ClientHandlerOrdering = iota
+	// ClientHandlerOrdered handles the requests one by one in arrival order.
+	ClientHandlerOrdered
+	// ClientHandlerOrderedByService keeps the arrival order of the requests
+	// of the same service header.
+	ClientHandlerOrderedByService
+)
+
+func (o ClientHandlerOrdering) String() string {
+	switch o {
+	case ClientHandlerUnordered:
+		return "unordered"
+	case ClientHandlerOrdered:
+		return "ordered"
+	case ClientHandlerOrderedByService:
+		return "ordered-by-service"
+	default:
+		return "unknown"
+	}
+}
+
+type clientHandlerJob struct {
+	crw *clientResponseWriter
+	req *Request
+}
+
+// clientHandlerPool runs the inbound requests of Client off the read loop. The
+// workers share a single queue unless the requests are ordered by service, then
+// every worker owns a queue of the services hashed to it.
+type clientHandlerPool struct {
+	c        *Client
+	ordering ClientHandlerOrdering
+	queues   []chan clientHandlerJob
+	wg       sync.WaitGroup
+}
+
+func newClientHandlerPool(c *Client, workers, pending int,
+	ordering ClientHandlerOrdering) *clientHandlerPool {
+	if ordering == ClientHandlerOrdered {
+		workers = 1
+	}
+
+	if workers <= 0 {
+		workers = 1
+	}
+
+	queues := 1
+	if ordering == ClientHandlerOrderedByService {
+		queues = workers
+	}
+
+	// every queue owns a slice of the pending requests
+	size := pending / queues
+	if size <= 0 {
+		size = 1
+	}
+
+	hp := &clientHandlerPool{
+		c:        c,
+		ordering: ordering,
+		queues:   make([]chan clientHandlerJob, queues),
+	}
+
+	for i := range hp.queues {
+		hp.queues[i] = make(chan clientHandlerJob, size)
+	}
+
+	for i := 0; i < workers; i++ {
+		hp.wg.Add(1)
+		go hp.work(hp.queues[i%queues])
+	}
+
+	return hp
+}
+
+func (hp *clientHandlerPool) work(queue chan clientHandlerJob) {
+	defer hp.wg.Done()
+	for job := range queue {
+		hp.c.options.handler.ServeSofaBOLT(job.crw, job.req)
+		releaseClientResponseWriter(job.crw)
+		ReleaseRequest(job.req)
+	}
+}
+
+// Serve queues a copy of raw and reports false if the pool is full.
+func (hp *clientHandlerPool) Serve(raw *Request) bool {
+	job := clientHandlerJob{
+		crw: acquireClientResponseWriter(hp.c),
+		req: AcquireRequest(),
+	}
+	job.req.CopyCommand(&raw.command)
+	job.crw.reset(hp.c).Derive(job.req)
+
+	var i int
+	if hp.ordering == ClientHandlerOrderedByService {
+		service := raw.GetHeaders().Get(fastsimplemap.StrService)
+		i = int(fnv32a(service) % uint32(len(hp.queues)))
+	}
+
+	select {
+	case hp.queues[i] <- job:
+		return true
+	default:
+	}
+
+	releaseClientResponseWriter(job.crw)
+	ReleaseRequest(job.req)
+	return false
+}
+
+// Stop waits all the queued requests done.
+func (hp *clientHandlerPool) Stop() {
+	for i := range hp.queues {
+		close(hp.queues[i])
+	}
+	hp.wg.Wait()
+}
+
+func fnv32a(s string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619
+	}
+	return h
+}
@@ -16,17 +16,30 @@ package sofabolt
 import (
 	"sync/atomic"
 	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt/histogram"
 )
 
 type ClientMetrics struct {
-	nread           int64
-	nwrite          int64
-	commands        int64
-	pendingCommands int64
-	references      int64
-	used            int64
-	lasted          int64
-	created         int64
+	nread            int64
+	nwrite           int64
+	commands         int64
+	pendingCommands  int64
+	pendingBytes     int64
+	blockedCallbacks int64
+	references       int64
+	used             int64
+	lasted           int64
+	lastUsed         int64 // unix nano
+	created          int64
+
+	// round-trip latency in nanoseconds and sizes in bytes
+	latency         histogram.Histogram
+	latencyByStatus histogram.Map
+	requestSize     histogram.Histogram
+	responseSize    histogram.Histogram
+	// compressed size in percent of the original size
+	compressionRatio histogram.Histogram
 }
 
 func (cm *ClientMetrics) GetBytesRead() int64         { return atomic.LoadInt64(&cm.nread) }
@@ -34,12 +47,90 @@ func (cm *ClientMetrics) GetBytesWrite() int64        { return atomic.LoadInt64(
 func (cm *ClientMetrics) GetCommands() int64          { return atomic.LoadInt64(&cm.commands) }
 func (cm *ClientMetrics) GetPendingCommands() int64   { return atomic.LoadInt64(&cm.pendingCommands) }
 func (cm *ClientMetrics) ResetPendingCommands()       { atomic.StoreInt64(&cm.pendingCommands, 0) }
+func (cm *ClientMetrics) GetPendingBytes() int64      { return atomic.LoadInt64(&cm.pendingBytes) }
+func (cm *ClientMetrics) GetBlockedCallbacks() int64  { return atomic.LoadInt64(&cm.blockedCallbacks) }
 func (cm *ClientMetrics) GetReferences() int64        { return atomic.LoadInt64(&cm.references) }
 func (cm *ClientMetrics) AddReferences(n int64) int64 { return atomic.AddInt64(&cm.references, n) }
 func (cm *ClientMetrics) GetUsed() int64              { return atomic.LoadInt64(&cm.used) }
 func (cm *ClientMetrics) AddUsed(n int64) int64       { return atomic.AddInt64(&cm.used, n) }
 func (cm *ClientMetrics) GetLasted() int64            { return atomic.LoadInt64(&cm.lasted) }
 func (cm *ClientMetrics) SetLasted() {
-	atomic.StoreInt64(&cm.lasted, time.Now().Unix())
+	now := time.Now()
+	atomic.StoreInt64(&cm.lasted, now.Unix())
+	atomic.StoreInt64(&cm.lastUsed, now.UnixNano())
 }
+
+// GetLastUsed returns the time the client was used last in unix nanoseconds.
+func (cm *ClientMetrics) GetLastUsed() int64 { return atomic.LoadInt64(&cm.lastUsed) }
+
 func (cm *ClientMetrics) GetCreated() int64 { return atomic.LoadInt64(&cm.created) }
+
+// GetLatency returns the histogram of round-trip latency in nanoseconds.
+func (cm *ClientMetrics) GetLatency() *histogram.Histogram { return &cm.latency }
+
+// GetLatencyByStatus returns the histogram of round-trip latency of the status.
+func (cm *ClientMetrics) GetLatencyByStatus(status Status) *histogram.Histogram {
+	return cm.latencyByStatus.Get(uint64(status))
+}
+
+// RangeLatencyByStatus calls fn with the latency histogram of every seen status.
+func (cm *ClientMetrics) RangeLatencyByStatus(fn func(status Status, h *histogram.Histogram) bool) {
+	cm.latencyByStatus.Range(func(key uint64, h *histogram.Histogram) bool {
+		return fn(Status(key), h)
+	})
+}
+
+// GetRequestSize returns the histogram of request size in bytes.
+func (cm *ClientMetrics) GetRequestSize() *histogram.Histogram { return &cm.requestSize }
+
+// GetResponseSize returns the histogram of response size in bytes.
+func (cm *ClientMetrics) GetResponseSize() *histogram.Histogram { return &cm.responseSize }
+
+// ObserveRoundTrip records the round-trip latency of a response.
+func (cm *ClientMetrics) ObserveRoundTrip(status Status, d time.Duration) {
+	cm.latency.Record(int64(d))
+	cm.latencyByStatus.Get(uint64(status)).Record(int64(d))
+}
+
+// ObserveRequestSize records the encoded size of a request.
+func (cm *ClientMetrics) ObserveRequestSize(n int) { cm.requestSize.Record(int64(n)) }
+
+// ObserveResponseSize records the decoded size of a response.
+func (cm *ClientMetrics) ObserveResponseSize(n int) { cm.responseSize.Record(int64(n)) }
+
+// GetCompressionRatio returns the histogram of the compressed size in percent of
+// the original size of the content written and read.
+func (cm *ClientMetrics) GetCompressionRatio() *histogram.Histogram { return &cm.compressionRatio }
+
+// ObserveCompression records the compressed and the original size of a content.
+func (cm *ClientMetrics) ObserveCompression(compressed, original int) {
+	cm.compressionRatio.Record(compressionRatio(compressed, original))
+}
+
+func (cm *ClientMetrics) addPendingBytes(n int64) { atomic.AddInt64(&cm.pendingBytes, n) }
+
+// merge adds the counters of o to cm.
+func (cm *ClientMetrics) merge(o *ClientMetrics) {
+	atomic.AddInt64(&cm.nread, o.GetBytesRead())
+	atomic.AddInt64(&cm.nwrite, o.GetBytesWrite())
+	atomic.AddInt64(&cm.commands, o.GetCommands())
+	atomic.AddInt64(&cm.pendingCommands, o.GetPendingCommands())
+	atomic.AddInt64(&cm.pendingBytes, o.GetPendingBytes())
+	atomic.AddInt64(&cm.blockedCallbacks, o.GetBlockedCallbacks())
+	atomic.AddInt64(&cm.references, o.GetReferences())
+	atomic.AddInt64(&cm.used, o.GetUsed())
+	if lasted := o.GetLasted(); lasted > cm.GetLasted() {
+		atomic.StoreInt64(&cm.lasted, lasted)
+	}
+	if lastUsed := o.GetLastUsed(); lastUsed > cm.GetLastUsed() {
+		atomic.StoreInt64(&cm.lastUsed, lastUsed)
+	}
+	if created := o.GetCreated(); cm.GetCreated() == 0 || created < cm.GetCreated() {
+		atomic.StoreInt64(&cm.created, created)
+	}
+	cm.latency.Merge(&o.latency)
+	cm.latencyByStatus.Merge(&o.latencyByStatus)
+	cm.requestSize.Merge(&o.requestSize)
+	cm.responseSize.Merge(&o.responseSize)
+	cm.compressionRatio.Merge(&o.compressionRatio)
+}
@@ -16,6 +16,8 @@ package sofabolt
 import (
 	"net"
 	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/capconn"
 )
 
 // ClientOptionSetter configures a client.
@@ -85,3 +87,87 @@ func WithClientHandler(handler Handler) ClientOptionSetterFunc {
 		c.options.handler = handler
 	})
 }
+
+// WithClientHandlerAsync runs the inbound requests on workers off the read
+// loop. The requests exceed pending are rejected with StatusServerThreadPoolBusy.
+func WithClientHandlerAsync(workers, pending int, ordering ClientHandlerOrdering) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.handlerAsync = true
+		c.options.handlerWorkers = workers
+		c.options.handlerPending = pending
+		c.options.handlerOrdering = ordering
+	})
+}
+
+// WithClientCallbackExecutor runs the response callbacks on the executor
+// instead of the read loop. The callback sees an owned copy of the response
+// which is valid until the callback returns.
+func WithClientCallbackExecutor(e Executor) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.callbackExecutor = e
+	})
+}
+
+// WithClientCallbackGuard reports the response callbacks running longer than
+// threshold through the metrics and onblocked.
+func WithClientCallbackGuard(threshold time.Duration,
+	onblocked func(ictx *InvokeContext, blocked time.Duration)) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.callbackBlockThreshold = threshold
+		c.options.onCallbackBlocked = onblocked
+	})
+}
+
+// WithClientAccessLog emits one record per sent request.
+func WithClientAccessLog(o *AccessLogOptions) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.accessLog = o
+	})
+}
+
+// WithClientSlow reports the requests slower than the threshold.
+func WithClientSlow(o *SlowOptions) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.slow = o
+	})
+}
+
+// WithClientCompression advertises compression to the server, which compresses the
+// responses by compression only if it's also configured. The content of the requests
+// of at least threshold bytes is compressed once a response accepted compression,
+// so the servers which are unaware never see it.
+func WithClientCompression(compression Compression, threshold int) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.compression = compression
+		c.options.compressionThreshold = threshold
+	})
+}
+
+// WithClientHeadersOptions configures the headers of the commands read.
+func WithClientHeadersOptions(o SimpleMapOptions) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.headers = o
+	})
+}
+
+// WithClientMaxDecompressedSize limits the decompressed content of the responses,
+// DefaultMaxDecompressedSize by default.
+func WithClientMaxDecompressedSize(n int) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.maxDecompressedSize = n
+	})
+}
+
+// WithClientTracer traces every call of the client by t, see the package tracing.
+func WithClientTracer(t ClientTracer) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.tracer = t
+	})
+}
+
+// WithClientCapture records the commands of the connection to r.
+func WithClientCapture(r capconn.Recorder) ClientOptionSetterFunc {
+	return ClientOptionSetterFunc(func(c *Client) {
+		c.options.capture = r
+	})
+}
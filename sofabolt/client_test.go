@@ -305,6 +305,11 @@ func TestClientMetrics(t *testing.T) {
 	require.Equal(t, int64(100), c.GetMetrics().GetUsed())
 	require.Equal(t, c.metrics.lasted, c.GetMetrics().GetLasted())
 	require.Equal(t, c.metrics.created, c.GetMetrics().GetCreated())
+	require.Equal(t, int64(count), c.GetMetrics().GetLatency().GetCount())
+	require.Equal(t, int64(count), c.GetMetrics().GetLatencyByStatus(StatusSuccess).GetCount())
+	require.Equal(t, int64(count), c.GetMetrics().GetRequestSize().GetCount())
+	require.Equal(t, int64(count), c.GetMetrics().GetResponseSize().GetCount())
+	require.Equal(t, int64(2200), c.GetMetrics().GetRequestSize().GetSum())
 	require.True(t, c.Closed())
 }
 
@@ -350,3 +355,160 @@ func TestClientWithMaxConcurrentRequests(t *testing.T) {
 		t.Error("expect timeout but not")
 	}
 }
+
+func TestClientHandlerAsync(t *testing.T) {
+	p0, p1 := net.Pipe()
+
+	client1, err := NewClient(
+		WithClientConn(p0),
+		WithClientHandlerAsync(2, 16, ClientHandlerUnordered),
+		WithClientHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			time.Sleep(500 * time.Millisecond)
+			rw.GetResponse().SetContentString("slow")
+			rw.Write()
+		})),
+	)
+	require.Nil(t, err)
+
+	client2, err := NewClient(
+		WithClientConn(p1),
+		WithClientHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			rw.GetResponse().SetContentString("fast")
+			rw.Write()
+		})),
+	)
+	require.Nil(t, err)
+
+	slowCh := make(chan string, 1)
+	err = client2.DoCallbackTimeout(AcquireRequest(), ClientCallbackerFunc(func(err error, ictx *InvokeContext) {
+		slowCh <- string(ictx.GetResponse().GetContent())
+	}), time.Second)
+	require.Nil(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	res := AcquireResponse()
+	started := time.Now()
+	require.Nil(t, client1.DoTimeout(AcquireRequest(), res, time.Second))
+	require.Equal(t, "fast", string(res.GetContent()))
+	require.Less(t, int64(time.Since(started)), int64(400*time.Millisecond))
+	require.Equal(t, "slow", <-slowCh)
+}
+
+func TestClientHandlerPoolUnordered(t *testing.T) {
+	var (
+		blockCh = make(chan struct{})
+		doneCh  = make(chan struct{}, 8)
+		c       = &Client{}
+	)
+	c.options.handler = HandlerFunc(func(rw ResponseWriter, req *Request) {
+		if string(req.GetContent()) == "slow" {
+			<-blockCh
+		}
+		doneCh <- struct{}{}
+	})
+
+	hp := newClientHandlerPool(c, 2, 8, ClientHandlerUnordered)
+	req := AcquireRequest()
+	defer ReleaseRequest(req)
+
+	req.SetContentString("slow")
+	require.True(t, hp.Serve(req))
+
+	// the requests queued are not stuck behind the slow one
+	req.SetContentString("fast")
+	for i := 0; i < 4; i++ {
+		require.True(t, hp.Serve(req))
+	}
+	for i := 0; i < 4; i++ {
+		select {
+		case <-doneCh:
+		case <-time.After(time.Second):
+			t.Fatal("request is stuck behind the slow one")
+		}
+	}
+
+	close(blockCh)
+	hp.Stop()
+}
+
+func TestClientHandlerAsyncBusy(t *testing.T) {
+	p0, p1 := net.Pipe()
+	blockCh := make(chan struct{})
+
+	_, err := NewClient(
+		WithClientConn(p0),
+		WithClientHandlerAsync(1, 1, ClientHandlerOrdered),
+		WithClientHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			<-blockCh
+			rw.Write()
+		})),
+	)
+	require.Nil(t, err)
+
+	client2, err := NewClient(WithClientConn(p1))
+	require.Nil(t, err)
+
+	var (
+		wg   sync.WaitGroup
+		busy int32
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := AcquireRequest()
+			res := AcquireResponse()
+			require.Nil(t, client2.DoTimeout(req, res, time.Second))
+			if res.GetStatus() == StatusServerThreadPoolBusy {
+				atomic.AddInt32(&busy, 1)
+			}
+		}()
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	close(blockCh)
+	wg.Wait()
+	require.GreaterOrEqual(t, atomic.LoadInt32(&busy), int32(2))
+}
+
+func TestClientCallbackExecutor(t *testing.T) {
+	p0, p1 := net.Pipe()
+	srv, err := NewServer(WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+		rw.GetResponse().SetContent(req.GetContent())
+		rw.Write()
+	})))
+	require.Nil(t, err)
+	go srv.ServeConn(p1)
+
+	blockedCh := make(chan time.Duration, 1)
+	c, err := NewClient(
+		WithClientConn(p0),
+		WithClientCallbackExecutor(GoExecutor),
+		WithClientCallbackGuard(100*time.Millisecond, func(ictx *InvokeContext, blocked time.Duration) {
+			blockedCh <- blocked
+		}),
+	)
+	require.Nil(t, err)
+
+	contentCh := make(chan string, 1)
+	req := AcquireRequest()
+	req.SetContentString("callback")
+	err = c.DoCallbackTimeout(req, ClientCallbackerFunc(func(err error, ictx *InvokeContext) {
+		time.Sleep(500 * time.Millisecond)
+		contentCh <- string(ictx.GetResponse().GetContent())
+	}), time.Second)
+	require.Nil(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	req2 := AcquireRequest()
+	req2.SetContentString("sync")
+	res := AcquireResponse()
+	started := time.Now()
+	require.Nil(t, c.DoTimeout(req2, res, time.Second))
+	require.Equal(t, "sync", string(res.GetContent()))
+	require.Less(t, int64(time.Since(started)), int64(400*time.Millisecond))
+
+	require.Equal(t, 100*time.Millisecond, <-blockedCh)
+	require.Equal(t, "callback", <-contentCh)
+	require.Equal(t, int64(1), c.GetMetrics().GetBlockedCallbacks())
+}
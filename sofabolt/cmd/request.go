@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -26,11 +27,19 @@ import (
 	"github.com/sofastack/sofa-common-go/helper/easyreader"
 )
 
-var requestBody string
+var (
+	requestBody    string
+	requestService string
+	requestMethod  string
+	requestJSON    bool
+)
 
 func init() {
 	fs := requestCmd.Flags()
 	fs.StringVarP(&requestBody, "request-body", "d", "", "Set the request body")
+	fs.StringVarP(&requestService, "service", "s", "", "Set the service unique id header")
+	fs.StringVarP(&requestMethod, "method", "m", "", "Set the method name header")
+	fs.BoolVar(&requestJSON, "json", false, "Send the request body as JSON (e.g. the args array of the method)")
 }
 
 var requestCmd = &cobra.Command{
@@ -78,6 +87,22 @@ var requestCmd = &cobra.Command{
 			req.SetContent(data)
 		}
 
+		if requestService != "" {
+			req.GetRPCHeaders().SetTargetService(requestService)
+		}
+
+		if requestMethod != "" {
+			req.GetRPCHeaders().SetMethodName(requestMethod)
+		}
+
+		if requestJSON {
+			if !json.Valid(req.GetContent()) {
+				log.Fatal("request body is not a valid json")
+			}
+			req.SetCodec(sofabolt.CodecJSON).SetClassString(sofabolt.ClassRequest)
+			req.GetHeaders().Set("content-type", sofabolt.ContentTypeJSON)
+		}
+
 		err = c.DoTimeout(req, res, 5*time.Second)
 		if err != nil {
 			log.Fatal(err)
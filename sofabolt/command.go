@@ -153,7 +153,9 @@ func (c Codec) String() string {
 	case CodecHessian2:
 		return "hessian2"
 	case CodecProtobuf:
-		return "prtobuf"
+		return "protobuf"
+	case CodecJSON:
+		return "json"
 	case CodecTBRemotingHessian2:
 		return "tbhessian2"
 	default:
@@ -179,6 +181,39 @@ const (
 	StatusServerDeseralException Status = 18 // 0x12
 )
 
+func (s Status) String() string {
+	switch s {
+	case StatusSuccess:
+		return "success"
+	case StatusError:
+		return "error"
+	case StatusServerException:
+		return "server-exception"
+	case StatusUnknown:
+		return "unknown"
+	case StatusServerThreadPoolBusy:
+		return "server-threadpool-busy"
+	case StatusErrorComm:
+		return "error-comm"
+	case StatusNoProcessor:
+		return "no-processor"
+	case StatusTimeout:
+		return "timeout"
+	case StatusClientSendError:
+		return "client-send-error"
+	case StatusCodecException:
+		return "codec-exception"
+	case StatusConnectionClosed:
+		return "connection-closed"
+	case StatusServerSerialException:
+		return "server-serial-exception"
+	case StatusServerDeseralException:
+		return "server-deserial-exception"
+	default:
+		return "unknown status"
+	}
+}
+
 type Command struct {
 	proto    Proto
 	ver1     Version
@@ -220,7 +255,8 @@ func (c *Command) Reset() {
 	c.content = c.content[:0]
 	c.crc32 = 0
 
-	c.headers.Reset()
+	// the headers options are set by ReadOption per read
+	c.headers.SetOptions(SimpleMapOptions{})
 }
 
 func (c *Command) IsRequest() bool {
@@ -300,8 +336,14 @@ func (c *Command) CopyTo(d *Command) {
 	c.headers.CopyTo(&d.headers)
 }
 
-// nolint
 func (c *Command) String() string {
+	return c.StringWithContentLimit(-1)
+}
+
+// StringWithContentLimit is like String but dumps at most limit bytes of the
+// content if limit is not negative.
+// nolint
+func (c *Command) StringWithContentLimit(limit int) string {
 	sep := []byte(",")
 	w := bytes.NewBuffer(make([]byte, 0, 64))
 
@@ -374,7 +416,12 @@ func (c *Command) String() string {
 	w.Write(sep)
 
 	w.WriteString("Content:")
-	w.WriteString(hex.EncodeToString(c.content))
+	if limit >= 0 && len(c.content) > limit {
+		w.WriteString(hex.EncodeToString(c.content[:limit]))
+		w.WriteString("...")
+	} else {
+		w.WriteString(hex.EncodeToString(c.content))
+	}
 	w.Write(sep)
 
 	w.WriteString("CRC32:")
@@ -407,11 +454,18 @@ func (c *Command) Size() int {
 }
 
 func (c *Command) Write(wo *WriteOption, b []byte) ([]byte, error) {
+	if (wo.compression != CompressionNone || wo.accept != CompressionNone) && c.proto != ProtoTBRemoting {
+		return writeCommandCompressed(wo, b, c)
+	}
 	return WriteCommand(wo, b, c)
 }
 
 func (c *Command) Read(ro *ReadOption, br io.Reader) (int, error) {
-	return ReadCommand(ro, br, c)
+	n, err := ReadCommand(ro, br, c)
+	if err != nil {
+		return n, err
+	}
+	return n, decompressCommand(ro, c)
 }
 
 // WriteCommand writes the command to []byte.
@@ -665,7 +719,10 @@ func writeRequestCommandBOLTV1(wo *WriteOption, b []byte, cmd *Command) ([]byte,
 
 // ReadCommand reads a command from io.Reader.
 func ReadCommand(ro *ReadOption, br io.Reader, cmd *Command) (int, error) {
-	_ = ro
+	if cmd.headers.GetOptions() != ro.headers {
+		cmd.headers.SetOptions(ro.headers)
+	}
+
 	var (
 		err error
 		u8  uint8
@@ -13,10 +13,61 @@
 
 package sofabolt
 
-type ReadOption struct{}
+type ReadOption struct {
+	headers             SimpleMapOptions
+	maxDecompressedSize int
+	compressed          int
+	original            int
+}
 
 func NewReadOption() *ReadOption { return &ReadOption{} }
 
-type WriteOption struct{}
+// SetHeadersOptions configures the headers of the commands read, e.g. to preserve
+// the order and the duplicate keys, or to limit the decoded entries.
+func (ro *ReadOption) SetHeadersOptions(o SimpleMapOptions) *ReadOption {
+	ro.headers = o
+	return ro
+}
+
+// SetMaxDecompressedSize limits the decompressed content, DefaultMaxDecompressedSize
+// if n is not positive. The commands inflating more fail with ErrDecompressedTooLarge.
+func (ro *ReadOption) SetMaxDecompressedSize(n int) *ReadOption {
+	ro.maxDecompressedSize = n
+	return ro
+}
+
+// GetCompressedSize returns the compressed and the original size of the content
+// last read, or zeros if the content was not compressed.
+func (ro *ReadOption) GetCompressedSize() (compressed, original int) {
+	return ro.compressed, ro.original
+}
+
+type WriteOption struct {
+	compression Compression
+	threshold   int
+	accept      Compression
+	compressed  int
+	original    int
+}
 
 func NewWriteOption() *WriteOption { return &WriteOption{} }
+
+// SetCompression compresses the content of at least threshold bytes by c. It
+// should only be set once the peer accepted c.
+func (wo *WriteOption) SetCompression(c Compression, threshold int) *WriteOption {
+	wo.compression = c
+	wo.threshold = threshold
+	return wo
+}
+
+// SetAcceptEncoding advertises c by the header accept-encoding.
+func (wo *WriteOption) SetAcceptEncoding(c Compression) *WriteOption {
+	wo.accept = c
+	return wo
+}
+
+// GetCompressedSize returns the compressed and the original size of the content
+// last written, or zeros if the content was not compressed.
+func (wo *WriteOption) GetCompressedSize() (compressed, original int) {
+	return wo.compressed, wo.original
+}
@@ -15,9 +15,11 @@ package sofabolt
 
 import (
 	"bytes"
+	"encoding/hex"
 	"testing"
 	"testing/iotest"
 
+	"github.com/sofastack/sofa-bolt-go/sofabolt/simplemap/fastsimplemap"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -178,3 +180,40 @@ func TestCommandReset(t *testing.T) {
 	assert.Zero(len(cmd.header))
 	assert.Zero(len(cmd.content))
 }
+
+func TestCommandStringWithContentLimit(t *testing.T) {
+	var cmd Command
+	cmd.SetContentString("hello world")
+	require.Contains(t, cmd.String(), "Content:"+hex.EncodeToString([]byte("hello world"))+",")
+	require.Contains(t, cmd.StringWithContentLimit(5), "Content:"+hex.EncodeToString([]byte("hello"))+"...,")
+	require.Equal(t, cmd.String(), cmd.StringWithContentLimit(64))
+}
+
+func TestReadCommandHeadersOptions(t *testing.T) {
+	var req Request
+	req.SetProto(ProtoBOLTV1).SetType(TypeBOLTRequest).SetCMDCode(CMDCodeBOLTRequest)
+	req.GetHeaders().SetOptions(SimpleMapOptions{Ordered: true})
+	req.GetHeaders().Add("x-forwarded-for", "a").Add("service", "s").Add("x-forwarded-for", "b")
+	b, err := req.Write(NewWriteOption(), nil)
+	require.Nil(t, err)
+
+	// the default headers keep the last value
+	var cmd Command
+	_, err = cmd.Read(NewReadOption(), bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Equal(t, []string{"b"}, cmd.GetHeaders().GetAll("x-forwarded-for"))
+
+	// the ordered headers encode the same bytes
+	_, err = cmd.Read(NewReadOption().SetHeadersOptions(SimpleMapOptions{Ordered: true}), bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b"}, cmd.GetHeaders().GetAll("x-forwarded-for"))
+	d, err := cmd.Write(NewWriteOption(), nil)
+	require.Nil(t, err)
+	require.Equal(t, b, d)
+
+	_, err = cmd.Read(NewReadOption().SetHeadersOptions(SimpleMapOptions{MaxEntries: 2}), bytes.NewReader(b))
+	require.Equal(t, fastsimplemap.ErrSimpleMapTooManyEntries, err)
+
+	cmd.Reset()
+	require.Equal(t, SimpleMapOptions{}, cmd.GetHeaders().GetOptions())
+}
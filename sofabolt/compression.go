@@ -0,0 +1,335 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+)
+
+// The content is compressed transparently: the writer sets the header
+// content-encoding to the compression, and the reader decompresses the content
+// and deletes the header. Both sides advertise the compression they accept by the
+// header accept-encoding, and only compress the content once the peer accepted
+// it: servers compress the responses of the requests which accept it, and clients
+// compress the requests after a response accepted it, so the peers which are
+// unaware still work.
+const (
+	headerContentEncoding = "content-encoding"
+	headerAcceptEncoding  = "accept-encoding"
+)
+
+// DefaultMaxDecompressedSize is the default limit of the decompressed content.
+const DefaultMaxDecompressedSize = 16 << 20
+
+var (
+	ErrCompressorNotFound   = errors.New("sofabolt: compressor of the content-encoding is not registered")
+	ErrDecompressedTooLarge = errors.New("sofabolt: decompressed content exceeds the limit")
+)
+
+// Compression is the name of a content compression in the header content-encoding.
+type Compression string
+
+const (
+	CompressionNone   Compression = ""
+	CompressionGzip   Compression = "gzip"
+	CompressionSnappy Compression = "snappy"
+	// CompressionZstd is only available when built with cgo.
+	CompressionZstd Compression = "zstd"
+)
+
+func (c Compression) String() string {
+	if c == CompressionNone {
+		return "none"
+	}
+	return string(c)
+}
+
+// Compressor compresses and decompresses the content.
+type Compressor interface {
+	// Compress appends the compressed src to dst.
+	Compress(dst, src []byte) ([]byte, error)
+	// Decompress appends the decompressed src to dst. It returns
+	// ErrDecompressedTooLarge rather than inflating more than max bytes.
+	Decompress(dst, src []byte, max int) ([]byte, error)
+}
+
+var compressors struct {
+	sync.RWMutex
+	m map[Compression]Compressor
+}
+
+// nolint
+func init() {
+	RegisterCompressor(CompressionGzip, gzipCompressor{})
+	RegisterCompressor(CompressionSnappy, snappyCompressor{})
+}
+
+// RegisterCompressor registers cp as the compressor of c and replaces the previous one.
+func RegisterCompressor(c Compression, cp Compressor) {
+	compressors.Lock()
+	if compressors.m == nil {
+		compressors.m = make(map[Compression]Compressor)
+	}
+	compressors.m[c] = cp
+	compressors.Unlock()
+}
+
+// GetCompressor returns the compressor of c.
+func GetCompressor(c Compression) (Compressor, bool) {
+	compressors.RLock()
+	cp, ok := compressors.m[c]
+	compressors.RUnlock()
+	return cp, ok
+}
+
+// acceptsCompression reports whether the header accept-encoding of the command
+// contains c.
+func acceptsCompression(cmd *Command, c Compression) bool {
+	if c == CompressionNone {
+		return false
+	}
+
+	accept := cmd.headers.Get(headerAcceptEncoding)
+	for accept != "" {
+		var name string
+		if i := strings.IndexByte(accept, ','); i >= 0 {
+			name, accept = accept[:i], accept[i+1:]
+		} else {
+			name, accept = accept, ""
+		}
+		if Compression(strings.TrimSpace(name)) == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+var compressCommandPool = sync.Pool{
+	New: func() interface{} { return &Command{} },
+}
+
+// writeCommandCompressed writes the command with the headers wo advertises, and the
+// content compressed if it reaches the threshold of wo. The command is left
+// untouched, the frame is built from a copy of the headers instead.
+func writeCommandCompressed(wo *WriteOption, b []byte, cmd *Command) ([]byte, error) {
+	wo.compressed, wo.original = 0, 0
+
+	accept := wo.accept != CompressionNone && cmd.headers.Get(headerAcceptEncoding) == ""
+	compress := wo.compression != CompressionNone && len(cmd.content) > 0 &&
+		len(cmd.content) >= wo.threshold && cmd.headers.Get(headerContentEncoding) == ""
+	if !accept && !compress {
+		return WriteCommand(wo, b, cmd)
+	}
+
+	var (
+		err error
+		dst *[]byte
+	)
+
+	if compress {
+		cp, ok := GetCompressor(wo.compression)
+		if !ok {
+			return b, ErrCompressorNotFound
+		}
+
+		dst = acquireBytes()
+		defer releaseBytes(dst)
+
+		*dst, err = cp.Compress((*dst)[:0], cmd.content)
+		if err != nil {
+			return b, err
+		}
+		compress = len(*dst) < len(cmd.content) // not worth it otherwise
+		if !accept && !compress {
+			return WriteCommand(wo, b, cmd)
+		}
+	}
+
+	tmp := compressCommandPool.Get().(*Command)
+	defer func() {
+		tmp.Reset()
+		compressCommandPool.Put(tmp)
+	}()
+
+	cmd.ShallowCopyTo(tmp)
+	cmd.headers.CopyTo(&tmp.headers)
+	if accept {
+		tmp.headers.Set(headerAcceptEncoding, string(wo.accept))
+	}
+	if compress {
+		tmp.headers.Set(headerContentEncoding, string(wo.compression))
+		tmp.content = *dst
+	}
+
+	b, err = WriteCommand(wo, b, tmp)
+	// the shallow copied buffers are owned by cmd and the pool
+	tmp.class, tmp.header, tmp.content = nil, nil, nil
+
+	if err == nil && compress {
+		wo.compressed, wo.original = len(*dst), len(cmd.content)
+	}
+
+	return b, err
+}
+
+// decompressCommand decompresses the content of the command in place if it has
+// the header content-encoding.
+func decompressCommand(ro *ReadOption, cmd *Command) error {
+	ro.compressed, ro.original = 0, 0
+
+	encoding := cmd.headers.Get(headerContentEncoding)
+	if encoding == "" {
+		return nil
+	}
+
+	cp, ok := GetCompressor(Compression(encoding))
+	if !ok {
+		return ErrCompressorNotFound
+	}
+
+	max := ro.maxDecompressedSize
+	if max <= 0 {
+		max = DefaultMaxDecompressedSize
+	}
+
+	dst := acquireBytes()
+	defer releaseBytes(dst)
+
+	var err error
+	*dst, err = cp.Decompress((*dst)[:0], cmd.content, max)
+	if err != nil {
+		return err
+	}
+
+	ro.compressed, ro.original = len(cmd.content), len(*dst)
+	// swap the buffers to avoid copying the content again
+	cmd.content, *dst = *dst, cmd.content[:0]
+	cmd.headers.Del(headerContentEncoding)
+
+	return nil
+}
+
+// compressionRatio returns the compressed size in percent of the original size.
+func compressionRatio(compressed, original int) int64 {
+	if original == 0 {
+		return 0
+	}
+	return int64(compressed) * 100 / int64(original)
+}
+
+var (
+	gzipWriterPool sync.Pool
+	gzipReaderPool sync.Pool
+)
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+
+	zw, ok := gzipWriterPool.Get().(*gzip.Writer)
+	if ok {
+		zw.Reset(buf)
+	} else {
+		zw = gzip.NewWriter(buf)
+	}
+	defer gzipWriterPool.Put(zw)
+
+	if _, err := zw.Write(src); err != nil {
+		return dst, err
+	}
+	if err := zw.Close(); err != nil {
+		return dst, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(dst, src []byte, max int) ([]byte, error) {
+	var (
+		err error
+		br  = bytes.NewReader(src)
+	)
+
+	zr, ok := gzipReaderPool.Get().(*gzip.Reader)
+	if ok {
+		err = zr.Reset(br)
+	} else {
+		zr, err = gzip.NewReader(br)
+	}
+	if err != nil {
+		return dst, err
+	}
+	defer gzipReaderPool.Put(zr)
+
+	return readLimited(dst, zr, max)
+}
+
+type snappyCompressor struct{}
+
+func (snappyCompressor) Compress(dst, src []byte) ([]byte, error) {
+	return append(dst, snappy.Encode(nil, src)...), nil
+}
+
+func (snappyCompressor) Decompress(dst, src []byte, max int) ([]byte, error) {
+	n, err := snappy.DecodedLen(src)
+	if err != nil {
+		return dst, err
+	}
+	if n > max {
+		return dst, ErrDecompressedTooLarge
+	}
+
+	l := len(dst)
+	dst = growBytes(dst, n)
+	b, err := snappy.Decode(dst[l:], src)
+	if err != nil {
+		return dst[:l], err
+	}
+
+	return dst[:l+len(b)], nil
+}
+
+// readLimited appends the data of r to dst, but at most max bytes.
+func readLimited(dst []byte, r io.Reader, max int) ([]byte, error) {
+	l := len(dst)
+	buf := bytes.NewBuffer(dst)
+	if _, err := buf.ReadFrom(io.LimitReader(r, int64(max)+1)); err != nil {
+		return dst[:l], err
+	}
+
+	if buf.Len()-l > max {
+		return dst[:l], ErrDecompressedTooLarge
+	}
+
+	return buf.Bytes(), nil
+}
+
+// growBytes returns b with n more bytes.
+func growBytes(b []byte, n int) []byte {
+	if cap(b)-len(b) >= n {
+		return b[:len(b)+n]
+	}
+	nb := make([]byte, len(b)+n)
+	copy(nb, b)
+	return nb
+}
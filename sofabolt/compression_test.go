@@ -0,0 +1,298 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressor(t *testing.T) {
+	src := bytes.Repeat([]byte("sofabolt compression "), 128)
+	for _, c := range []Compression{CompressionGzip, CompressionSnappy, CompressionZstd} {
+		cp, ok := GetCompressor(c)
+		if !ok {
+			t.Logf("compressor %s is not registered", c)
+			continue
+		}
+
+		dst, err := cp.Compress([]byte("prefix"), src)
+		require.Nil(t, err, c.String())
+		require.Equal(t, "prefix", string(dst[:6]))
+		require.True(t, len(dst) < len(src), c.String())
+
+		b, err := cp.Decompress([]byte("prefix"), dst[6:], len(src))
+		require.Nil(t, err, c.String())
+		require.Equal(t, "prefix", string(b[:6]))
+		require.Equal(t, src, b[6:], c.String())
+
+		b, err = cp.Decompress([]byte("prefix"), dst[6:], len(src)-1)
+		require.Equal(t, ErrDecompressedTooLarge, err, c.String())
+		require.Equal(t, "prefix", string(b))
+
+		_, err = cp.Decompress(nil, []byte("garbage"), len(src))
+		require.NotNil(t, err, c.String())
+	}
+
+	_, ok := GetCompressor(Compression("lz4"))
+	require.False(t, ok)
+	require.Equal(t, "none", CompressionNone.String())
+}
+
+func TestCommandCompression(t *testing.T) {
+	content := bytes.Repeat([]byte("abcd"), 256)
+
+	var req Request
+	req.SetProto(ProtoBOLTV1).SetType(TypeBOLTRequest).SetCMDCode(CMDCodeBOLTRequest)
+	req.SetContent(content)
+	req.GetHeaders().Set("service", "test")
+
+	wo := NewWriteOption().SetCompression(CompressionGzip, 512).SetAcceptEncoding(CompressionGzip)
+	b, err := req.Write(wo, nil)
+	require.Nil(t, err)
+	compressed, original := wo.GetCompressedSize()
+	require.Equal(t, len(content), original)
+	require.True(t, compressed > 0 && compressed < original)
+	// the request is restored
+	require.Equal(t, content, req.GetContent())
+	require.Equal(t, "", req.GetHeaders().Get(headerContentEncoding))
+	require.Equal(t, "", req.GetHeaders().Get(headerAcceptEncoding))
+
+	// an unaware reader sees the compressed content
+	var raw Command
+	_, err = ReadCommand(NewReadOption(), bytes.NewReader(b), &raw)
+	require.Nil(t, err)
+	require.Equal(t, "gzip", raw.GetHeaders().Get(headerContentEncoding))
+	require.Equal(t, "gzip", raw.GetHeaders().Get(headerAcceptEncoding))
+	require.Equal(t, compressed, len(raw.GetContent()))
+	require.True(t, acceptsCompression(&raw, CompressionGzip))
+	require.False(t, acceptsCompression(&raw, CompressionSnappy))
+
+	var got Request
+	ro := NewReadOption()
+	_, err = got.Read(ro, bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Equal(t, content, got.GetContent())
+	require.Equal(t, "", got.GetHeaders().Get(headerContentEncoding))
+	require.Equal(t, "test", got.GetHeaders().Get("service"))
+	compressed, original = ro.GetCompressedSize()
+	require.Equal(t, len(content), original)
+	require.Equal(t, len(raw.GetContent()), compressed)
+
+	// below the threshold
+	wo.SetCompression(CompressionGzip, 2048)
+	b, err = req.Write(wo, b[:0])
+	require.Nil(t, err)
+	compressed, _ = wo.GetCompressedSize()
+	require.Equal(t, 0, compressed)
+	_, err = got.Read(ro, bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Equal(t, content, got.GetContent())
+	compressed, _ = ro.GetCompressedSize()
+	require.Equal(t, 0, compressed)
+
+	// unknown content-encoding
+	raw.GetHeaders().Set(headerContentEncoding, "lz4")
+	b, err = raw.Write(NewWriteOption(), b[:0])
+	require.Nil(t, err)
+	_, err = got.Read(ro, bytes.NewReader(b))
+	require.Equal(t, ErrCompressorNotFound, err)
+
+	// the headers set by the caller are kept
+	req.GetHeaders().Set(headerAcceptEncoding, "zstd")
+	b, err = req.Write(NewWriteOption().SetAcceptEncoding(CompressionGzip), b[:0])
+	require.Nil(t, err)
+	got.Reset()
+	_, err = got.Read(ro, bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Equal(t, "zstd", got.GetHeaders().Get(headerAcceptEncoding))
+	require.Equal(t, "zstd", req.GetHeaders().Get(headerAcceptEncoding))
+	req.GetHeaders().Del(headerAcceptEncoding)
+
+	_, err = req.Write(NewWriteOption().SetCompression(Compression("lz4"), 0), nil)
+	require.Equal(t, ErrCompressorNotFound, err)
+
+	var c Command
+	c.GetHeaders().Set(headerAcceptEncoding, "zstd, gzip")
+	require.True(t, acceptsCompression(&c, CompressionGzip))
+	require.True(t, acceptsCompression(&c, CompressionZstd))
+	require.False(t, acceptsCompression(&c, CompressionNone))
+}
+
+func TestClientServerCompression(t *testing.T) {
+	content := bytes.Repeat([]byte("abcd"), 1024)
+
+	sm := &ServerMetrics{}
+	srv, err := NewServer(
+		WithServerMetrics(sm),
+		WithServerCompression(CompressionSnappy, 1024),
+		WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			rw.GetResponse().SetContent(req.GetContent())
+			// nolint
+			rw.Write()
+		})),
+	)
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go func() {
+		// nolint
+		srv.ServeConn(p0)
+	}()
+
+	cm := &ClientMetrics{}
+	c, err := NewClient(
+		WithClientConn(p1),
+		WithClientMetrics(cm),
+		WithClientCompression(CompressionSnappy, 1024),
+	)
+	require.Nil(t, err)
+	defer c.Close()
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	defer ReleaseRequest(req)
+	defer ReleaseResponse(res)
+
+	// the request is not compressed until the server accepted it
+	req.SetContent(content)
+	require.Nil(t, c.DoTimeout(req, res, time.Second))
+	require.Equal(t, content, res.GetContent())
+	require.Equal(t, "", res.GetHeaders().Get(headerContentEncoding))
+	require.Equal(t, int64(1), cm.GetCompressionRatio().GetCount())
+	require.Equal(t, int64(1), sm.GetCompressionRatio().GetCount())
+
+	require.Nil(t, c.DoTimeout(req, res, time.Second))
+	require.Equal(t, content, res.GetContent())
+	require.Equal(t, int64(3), cm.GetCompressionRatio().GetCount())
+	require.True(t, cm.GetCompressionRatio().GetSum() < 3*100)
+	require.Equal(t, int64(3), sm.GetCompressionRatio().GetCount())
+
+	// small contents are not compressed
+	req.SetContent([]byte("abcd"))
+	require.Nil(t, c.DoTimeout(req, res, time.Second))
+	require.Equal(t, "abcd", string(res.GetContent()))
+	require.Equal(t, int64(3), cm.GetCompressionRatio().GetCount())
+}
+
+func TestClientCompressionUnawareServer(t *testing.T) {
+	content := bytes.Repeat([]byte("abcd"), 1024)
+
+	srv, err := NewServer(
+		WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			if req.GetHeaders().Get(headerContentEncoding) != "" {
+				rw.GetResponse().SetStatus(StatusServerDeseralException)
+			}
+			rw.GetResponse().SetContent(req.GetContent())
+			// nolint
+			rw.Write()
+		})),
+	)
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go func() {
+		// nolint
+		srv.ServeConn(p0)
+	}()
+
+	cm := &ClientMetrics{}
+	c, err := NewClient(
+		WithClientConn(p1),
+		WithClientMetrics(cm),
+		WithClientCompression(CompressionGzip, 0),
+	)
+	require.Nil(t, err)
+	defer c.Close()
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	defer ReleaseRequest(req)
+	defer ReleaseResponse(res)
+
+	req.SetContent(content)
+	for i := 0; i < 3; i++ {
+		require.Nil(t, c.DoTimeout(req, res, time.Second))
+		require.Equal(t, StatusSuccess, res.GetStatus())
+		require.Equal(t, content, res.GetContent())
+	}
+	require.Equal(t, int64(0), cm.GetCompressionRatio().GetCount())
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	bomb := make([]byte, DefaultMaxDecompressedSize+1)
+	for _, c := range []Compression{CompressionGzip, CompressionSnappy, CompressionZstd} {
+		cp, ok := GetCompressor(c)
+		if !ok {
+			continue
+		}
+
+		compressed, err := cp.Compress(nil, bomb)
+		require.Nil(t, err, c.String())
+		require.True(t, len(compressed) < len(bomb)/10, c.String())
+
+		var req Request
+		req.SetProto(ProtoBOLTV1).SetType(TypeBOLTRequest).SetCMDCode(CMDCodeBOLTRequest)
+		req.SetContent(compressed)
+		req.GetHeaders().Set(headerContentEncoding, string(c))
+		b, err := req.Write(NewWriteOption(), nil)
+		require.Nil(t, err, c.String())
+
+		var got Request
+		_, err = got.Read(NewReadOption(), bytes.NewReader(b))
+		require.Equal(t, ErrDecompressedTooLarge, err, c.String())
+
+		_, err = got.Read(NewReadOption().SetMaxDecompressedSize(len(bomb)), bytes.NewReader(b))
+		require.Nil(t, err, c.String())
+		require.Equal(t, len(bomb), len(got.GetContent()), c.String())
+	}
+}
+
+func TestServerCompressionUnaccepted(t *testing.T) {
+	content := bytes.Repeat([]byte("abcd"), 1024)
+
+	srv, err := NewServer(
+		WithServerCompression(CompressionGzip, 0),
+		WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			rw.GetResponse().SetContent(content)
+			// nolint
+			rw.Write()
+		})),
+	)
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go func() {
+		// nolint
+		srv.ServeConn(p0)
+	}()
+
+	cm := &ClientMetrics{}
+	c, err := NewClient(WithClientConn(p1), WithClientMetrics(cm))
+	require.Nil(t, err)
+	defer c.Close()
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	defer ReleaseRequest(req)
+	defer ReleaseResponse(res)
+
+	require.Nil(t, c.DoTimeout(req, res, time.Second))
+	require.Equal(t, content, res.GetContent())
+	require.Equal(t, int64(0), cm.GetCompressionRatio().GetCount())
+}
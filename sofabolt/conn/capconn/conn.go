@@ -0,0 +1,140 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package capconn
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxPending bounds the bytes buffered for an incomplete frame.
+const maxPending = 16 << 20
+
+type Conn struct {
+	net.Conn
+	recorder Recorder
+	splitter Splitter
+	in       stream
+	out      stream
+}
+
+type stream struct {
+	sync.Mutex
+	direction Direction
+	pending   []byte
+	raw       bool
+}
+
+// New wraps conn to record the frames to recorder. The data is split into
+// frames by splitter, or recorded as is if splitter is nil.
+func New(conn net.Conn, recorder Recorder, splitter Splitter) *Conn {
+	return &Conn{
+		Conn:     conn,
+		recorder: recorder,
+		splitter: splitter,
+		in:       stream{direction: DirectionInbound},
+		out:      stream{direction: DirectionOutbound},
+	}
+}
+
+// filer describes an object that has ability to return os.File.
+type filer interface {
+	// File returns a copy of object's file descriptor.
+	File() (*os.File, error)
+}
+
+// File returns a copy of object's file descriptor.
+func (c *Conn) File() (*os.File, error) {
+	if sf, ok := c.Conn.(filer); ok {
+		return sf.File()
+	}
+
+	return nil, errors.New("not implement filer interface")
+}
+
+func (c *Conn) SyscallConn() (syscall.RawConn, error) {
+	if sf, ok := c.Conn.(syscall.Conn); ok {
+		return sf.SyscallConn()
+	}
+
+	return nil, errors.New("not implement syscall.Conn interface")
+}
+
+func (c *Conn) GetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.record(&c.in, p[:n])
+	}
+	return n, err
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if n > 0 {
+		c.record(&c.out, p[:n])
+	}
+	return n, err
+}
+
+func (c *Conn) record(s *stream, p []byte) {
+	now := time.Now()
+
+	s.Lock()
+	defer s.Unlock()
+
+	if c.splitter == nil || s.raw {
+		c.emit(s.direction, now, p)
+		return
+	}
+
+	s.pending = append(s.pending, p...)
+	off := 0
+	for off < len(s.pending) {
+		n, err := c.splitter(s.pending[off:])
+		if err != nil || len(s.pending)-off > maxPending {
+			// Give up splitting the malformed stream
+			c.emit(s.direction, now, s.pending[off:])
+			s.pending = nil
+			s.raw = true
+			return
+		}
+
+		if n == 0 || n > len(s.pending)-off {
+			break
+		}
+
+		c.emit(s.direction, now, s.pending[off:off+n])
+		off += n
+	}
+	s.pending = s.pending[:copy(s.pending, s.pending[off:])]
+}
+
+func (c *Conn) emit(d Direction, now time.Time, data []byte) {
+	// nolint
+	c.recorder.Record(&Frame{
+		Time:       now,
+		Direction:  d,
+		LocalAddr:  c.Conn.LocalAddr(),
+		RemoteAddr: c.Conn.RemoteAddr(),
+		Data:       data,
+	})
+}
@@ -0,0 +1,203 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package capconn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type frameRecorder struct {
+	sync.Mutex
+	frames []Frame
+}
+
+func (r *frameRecorder) Record(f *Frame) error {
+	r.Lock()
+	c := *f
+	c.Data = append([]byte(nil), f.Data...)
+	r.frames = append(r.frames, c)
+	r.Unlock()
+	return nil
+}
+
+func (r *frameRecorder) get() []Frame {
+	r.Lock()
+	defer r.Unlock()
+	return append([]Frame(nil), r.frames...)
+}
+
+// lengthSplitter splits the frames prefixed by 1 byte length.
+func lengthSplitter(b []byte) (int, error) {
+	if b[0] == 0 {
+		return 0, errors.New("zero length")
+	}
+	if len(b) < int(b[0])+1 {
+		return 0, nil
+	}
+	return int(b[0]) + 1, nil
+}
+
+func TestConnSplit(t *testing.T) {
+	p0, p1 := net.Pipe()
+	recorder := &frameRecorder{}
+	c := New(p0, recorder, lengthSplitter)
+
+	go func() {
+		p1.Write([]byte{3, 'a', 'b'})         // nolint
+		p1.Write([]byte{'c', 1, 'd', 2, 'e'}) // nolint
+		p1.Write([]byte{'f', 0, 'g'})         // nolint
+	}()
+
+	var p [16]byte
+	for n := 0; n < 11; {
+		m, err := c.Read(p[:])
+		require.Nil(t, err)
+		n += m
+	}
+
+	go p1.Read(p[:]) // nolint
+	_, err := c.Write([]byte{1, 'x'})
+	require.Nil(t, err)
+
+	frames := recorder.get()
+	require.Len(t, frames, 5)
+	require.Equal(t, []byte{3, 'a', 'b', 'c'}, frames[0].Data)
+	require.Equal(t, []byte{1, 'd'}, frames[1].Data)
+	require.Equal(t, []byte{2, 'e', 'f'}, frames[2].Data)
+	// the malformed stream is recorded as is
+	require.Equal(t, []byte{0, 'g'}, frames[3].Data)
+	require.Equal(t, DirectionInbound, frames[3].Direction)
+	require.Equal(t, DirectionOutbound, frames[4].Direction)
+	require.Equal(t, []byte{1, 'x'}, frames[4].Data)
+}
+
+func TestTextRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	tr := NewTextRecorder(&buf, nil)
+	require.Nil(t, tr.Record(&Frame{
+		Time:       time.Unix(0, 0).UTC(),
+		Direction:  DirectionOutbound,
+		LocalAddr:  &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000},
+		RemoteAddr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 12200},
+		Data:       []byte("ab"),
+	}))
+	require.Equal(t, "1970-01-01T00:00:00Z out 10.0.0.1:5000->10.0.0.2:12200 len=2 6162\n", buf.String())
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capconn")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "capture")
+	rf, err := NewRotatingFile(path, 10, 2)
+	require.Nil(t, err)
+	require.Nil(t, rf.SetHeader(func(w io.Writer) error {
+		_, err := w.Write([]byte("H"))
+		return err
+	}))
+
+	for _, s := range []string{"aaaa", "bbbb", "cccc", "dddd", "eeee", "ffff"} {
+		_, err = rf.Write([]byte(s))
+		require.Nil(t, err)
+	}
+	require.Nil(t, rf.Close())
+
+	read := func(p string) string {
+		b, err := ioutil.ReadFile(p)
+		require.Nil(t, err)
+		return string(b)
+	}
+	require.Equal(t, "Heeeeffff", read(path))
+	require.Equal(t, "Hccccdddd", read(path+".1"))
+	require.Equal(t, "Haaaabbbb", read(path+".2"))
+	_, err = os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestPcapngRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	pr, err := NewPcapngRecorder(&buf)
+	require.Nil(t, err)
+
+	local := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}
+	remote := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 12200}
+	now := time.Now()
+	require.Nil(t, pr.Record(&Frame{Time: now, Direction: DirectionOutbound,
+		LocalAddr: local, RemoteAddr: remote, Data: []byte("hello")}))
+	require.Nil(t, pr.Record(&Frame{Time: now, Direction: DirectionInbound,
+		LocalAddr: local, RemoteAddr: remote, Data: []byte("world!")}))
+	require.Nil(t, pr.Record(&Frame{Time: now, Direction: DirectionOutbound,
+		LocalAddr: local, RemoteAddr: remote, Data: []byte("bye")}))
+
+	b := buf.Bytes()
+	le := binary.LittleEndian
+	require.Equal(t, uint32(pcapngBlockSHB), le.Uint32(b))
+	require.Equal(t, uint32(pcapngMagic), le.Uint32(b[8:]))
+	require.Equal(t, uint32(pcapngBlockIDB), le.Uint32(b[28:]))
+	require.Equal(t, uint16(pcapngLinkTypeRaw), le.Uint16(b[36:]))
+
+	type packet struct {
+		src, dst net.IP
+		sport    uint16
+		seq, ack uint32
+		payload  string
+	}
+	var packets []packet
+	for off := 48; off < len(b); {
+		require.Equal(t, uint32(pcapngBlockEPB), le.Uint32(b[off:]))
+		total := int(le.Uint32(b[off+4:]))
+		require.Equal(t, uint32(total), le.Uint32(b[off+total-4:]))
+		caplen := int(le.Uint32(b[off+20:]))
+		pkt := b[off+28 : off+28+caplen]
+		require.Equal(t, uint16(0), checksum(0, pkt[:ipv4HeaderLen]))
+		tcp := pkt[ipv4HeaderLen:]
+		var flow pcapngFlow
+		copy(flow.src.ip[:], pkt[12:16])
+		copy(flow.dst.ip[:], pkt[16:20])
+		require.Equal(t, uint16(0), tcpChecksum(flow, tcp))
+		packets = append(packets, packet{
+			src:     net.IP(pkt[12:16]),
+			dst:     net.IP(pkt[16:20]),
+			sport:   binary.BigEndian.Uint16(tcp),
+			seq:     binary.BigEndian.Uint32(tcp[4:]),
+			ack:     binary.BigEndian.Uint32(tcp[8:]),
+			payload: string(tcp[tcpHeaderLen:]),
+		})
+		off += total
+	}
+
+	require.Len(t, packets, 3)
+	require.Equal(t, "10.0.0.1", packets[0].src.String())
+	require.Equal(t, uint16(5000), packets[0].sport)
+	require.Equal(t, "hello", packets[0].payload)
+	require.Equal(t, "10.0.0.2", packets[1].src.String())
+	require.Equal(t, uint32(1), packets[1].seq)
+	require.Equal(t, uint32(6), packets[1].ack)
+	require.Equal(t, uint32(6), packets[2].seq)
+	require.Equal(t, uint32(7), packets[2].ack)
+	require.Equal(t, "bye", packets[2].payload)
+}
@@ -0,0 +1,93 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+// Package capconn records the frames read from and written to a connection.
+package capconn
+
+import (
+	"net"
+	"time"
+)
+
+type Direction uint8
+
+const (
+	// DirectionInbound is the data read from the peer.
+	DirectionInbound Direction = 1
+	// DirectionOutbound is the data written to the peer.
+	DirectionOutbound Direction = 2
+)
+
+func (d Direction) String() string {
+	switch d {
+	case DirectionInbound:
+		return "in"
+	case DirectionOutbound:
+		return "out"
+	default:
+		return "unknown"
+	}
+}
+
+// Frame is a complete protocol frame, or a raw chunk if the conn has no
+// Splitter, seen on the connection.
+type Frame struct {
+	Time       time.Time
+	Direction  Direction
+	LocalAddr  net.Addr
+	RemoteAddr net.Addr
+	Data       []byte
+}
+
+// Source returns the address which sent the frame.
+func (f *Frame) Source() net.Addr {
+	if f.Direction == DirectionInbound {
+		return f.RemoteAddr
+	}
+	return f.LocalAddr
+}
+
+// Destination returns the address which received the frame.
+func (f *Frame) Destination() net.Addr {
+	if f.Direction == DirectionInbound {
+		return f.LocalAddr
+	}
+	return f.RemoteAddr
+}
+
+// Recorder records the frames. Data of the frame must not be retained after
+// Record returns.
+type Recorder interface {
+	Record(f *Frame) error
+}
+
+type RecorderFunc func(f *Frame) error
+
+func (r RecorderFunc) Record(f *Frame) error { return r(f) }
+
+// MultiRecorder records frames to all the recorders.
+func MultiRecorder(recorders ...Recorder) Recorder {
+	return RecorderFunc(func(f *Frame) error {
+		var err error
+		for _, r := range recorders {
+			if rerr := r.Record(f); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+		return err
+	})
+}
+
+// Splitter returns the length of the first frame in b, or 0 if b does not
+// hold a complete frame yet.
+type Splitter func(b []byte) (int, error)
@@ -0,0 +1,247 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package capconn
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+const (
+	pcapngBlockSHB = 0x0A0D0D0A
+	pcapngBlockIDB = 0x00000001
+	pcapngBlockEPB = 0x00000006
+	pcapngMagic    = 0x1A2B3C4D
+	// LINKTYPE_RAW: the packets begin with an IPv4 or IPv6 header
+	pcapngLinkTypeRaw = 101
+
+	ipv4HeaderLen = 20
+	tcpHeaderLen  = 20
+	// maxSegment keeps the synthetic IPv4 packets below 64KiB
+	maxSegment = 65535 - ipv4HeaderLen - tcpHeaderLen
+
+	tcpFlagPSH = 0x08
+	tcpFlagACK = 0x10
+)
+
+var (
+	// The endpoints used if the address of the frame is not a TCP/IPv4 address.
+	FallbackLocalAddr  = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}
+	FallbackRemoteAddr = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 12200}
+)
+
+// PcapngRecorder writes the frames as synthetic TCP/IPv4 packets in the
+// pcapng format so that the capture can be opened by Wireshark.
+type PcapngRecorder struct {
+	sync.Mutex
+	w     io.Writer
+	flows map[pcapngFlow]uint32
+	buf   []byte
+}
+
+type pcapngEndpoint struct {
+	ip   [4]byte
+	port uint16
+}
+
+type pcapngFlow struct {
+	src pcapngEndpoint
+	dst pcapngEndpoint
+}
+
+// NewPcapngRecorder writes the pcapng header to w and returns the recorder.
+// The header is written to every new file if w is a *RotatingFile.
+func NewPcapngRecorder(w io.Writer) (*PcapngRecorder, error) {
+	var err error
+	if rf, ok := w.(*RotatingFile); ok {
+		err = rf.SetHeader(WritePcapngHeader)
+	} else {
+		err = WritePcapngHeader(w)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &PcapngRecorder{
+		w:     w,
+		flows: make(map[pcapngFlow]uint32),
+	}, nil
+}
+
+// WritePcapngHeader writes the section header block and the interface
+// description block.
+func WritePcapngHeader(w io.Writer) error {
+	b := make([]byte, 0, 48)
+
+	// Section Header Block
+	b = appendUint32(b, pcapngBlockSHB)
+	b = appendUint32(b, 28)
+	b = appendUint32(b, pcapngMagic)
+	b = appendUint16(b, 1) // major version
+	b = appendUint16(b, 0) // minor version
+	b = appendUint32(b, 0xFFFFFFFF)
+	b = appendUint32(b, 0xFFFFFFFF) // unspecified section length
+	b = appendUint32(b, 28)
+
+	// Interface Description Block
+	b = appendUint32(b, pcapngBlockIDB)
+	b = appendUint32(b, 20)
+	b = appendUint16(b, pcapngLinkTypeRaw)
+	b = appendUint16(b, 0) // reserved
+	b = appendUint32(b, 0) // no snap length limit
+	b = appendUint32(b, 20)
+
+	_, err := w.Write(b)
+	return err
+}
+
+func (pr *PcapngRecorder) Record(f *Frame) error {
+	src := toEndpoint(f.Source(), f.Direction == DirectionOutbound)
+	dst := toEndpoint(f.Destination(), f.Direction == DirectionInbound)
+	flow := pcapngFlow{src: src, dst: dst}
+	reverse := pcapngFlow{src: dst, dst: src}
+	ts := uint64(f.Time.UnixNano() / 1000) // microseconds
+
+	pr.Lock()
+	defer pr.Unlock()
+
+	b := pr.buf[:0]
+	data := f.Data
+	for len(data) > 0 || len(b) == 0 {
+		n := len(data)
+		if n > maxSegment {
+			n = maxSegment
+		}
+
+		seq := pr.flows[flow] + 1
+		ack := pr.flows[reverse] + 1
+		b = appendEPB(b, ts, flow, seq, ack, data[:n])
+		pr.flows[flow] += uint32(n)
+		data = data[n:]
+	}
+	pr.buf = b
+
+	_, err := pr.w.Write(b)
+	return err
+}
+
+func appendEPB(b []byte, ts uint64, flow pcapngFlow, seq, ack uint32, payload []byte) []byte {
+	caplen := ipv4HeaderLen + tcpHeaderLen + len(payload)
+	padding := (4 - caplen%4) % 4
+	total := uint32(32 + caplen + padding)
+
+	b = appendUint32(b, pcapngBlockEPB)
+	b = appendUint32(b, total)
+	b = appendUint32(b, 0) // interface id
+	b = appendUint32(b, uint32(ts>>32))
+	b = appendUint32(b, uint32(ts))
+	b = appendUint32(b, uint32(caplen))
+	b = appendUint32(b, uint32(caplen))
+
+	// IPv4 header
+	ip := len(b)
+	b = append(b, 0x45, 0)
+	b = appendBEUint16(b, uint16(caplen))
+	b = append(b, 0, 0, 0x40, 0) // id, don't fragment
+	b = append(b, 64, 6, 0, 0)   // ttl, tcp, checksum
+	b = append(b, flow.src.ip[:]...)
+	b = append(b, flow.dst.ip[:]...)
+	binary.BigEndian.PutUint16(b[ip+10:], checksum(0, b[ip:ip+ipv4HeaderLen]))
+
+	// TCP header
+	tcp := len(b)
+	b = appendBEUint16(b, flow.src.port)
+	b = appendBEUint16(b, flow.dst.port)
+	b = appendBEUint32(b, seq)
+	b = appendBEUint32(b, ack)
+	b = append(b, (tcpHeaderLen/4)<<4, tcpFlagPSH|tcpFlagACK)
+	b = appendBEUint16(b, 65535) // window
+	b = append(b, 0, 0, 0, 0)    // checksum, urgent pointer
+	b = append(b, payload...)
+	binary.BigEndian.PutUint16(b[tcp+16:], tcpChecksum(flow, b[tcp:]))
+
+	for i := 0; i < padding; i++ {
+		b = append(b, 0)
+	}
+	return appendUint32(b, total)
+}
+
+func toEndpoint(addr net.Addr, local bool) pcapngEndpoint {
+	var e pcapngEndpoint
+	if ta, ok := addr.(*net.TCPAddr); ok && ta.IP.To4() != nil {
+		copy(e.ip[:], ta.IP.To4())
+		e.port = uint16(ta.Port)
+		return e
+	}
+
+	fallback := FallbackRemoteAddr
+	if local {
+		fallback = FallbackLocalAddr
+	}
+	copy(e.ip[:], fallback.IP.To4())
+	e.port = uint16(fallback.Port)
+	return e
+}
+
+func tcpChecksum(flow pcapngFlow, segment []byte) uint16 {
+	var pseudo [12]byte
+	copy(pseudo[0:4], flow.src.ip[:])
+	copy(pseudo[4:8], flow.dst.ip[:])
+	pseudo[9] = 6
+	binary.BigEndian.PutUint16(pseudo[10:], uint16(len(segment)))
+	return checksum(sum(0, pseudo[:]), segment)
+}
+
+func checksum(initial uint32, b []byte) uint16 {
+	s := sum(initial, b)
+	for s>>16 != 0 {
+		s = (s & 0xFFFF) + (s >> 16)
+	}
+	return ^uint16(s)
+}
+
+func sum(s uint32, b []byte) uint32 {
+	for i := 0; i+1 < len(b); i += 2 {
+		s += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		s += uint32(b[len(b)-1]) << 8
+	}
+	return s
+}
+
+// The pcapng blocks are written in little endian as declared by the magic of
+// the section header, the packets are in the network byte order.
+
+func appendUint16(b []byte, v uint16) []byte {
+	var p [2]byte
+	binary.LittleEndian.PutUint16(p[:], v)
+	return append(b, p[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var p [4]byte
+	binary.LittleEndian.PutUint32(p[:], v)
+	return append(b, p[:]...)
+}
+
+func appendBEUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendBEUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
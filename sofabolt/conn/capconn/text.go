@@ -0,0 +1,73 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package capconn
+
+import (
+	"encoding/hex"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TextRecorder writes one line per frame with the timestamp, direction,
+// addresses, length and the formatted data.
+type TextRecorder struct {
+	sync.Mutex
+	w      io.Writer
+	format func(data []byte) string
+	buf    []byte
+}
+
+// NewTextRecorder returns a TextRecorder which formats the data by format,
+// or dumps the data in hex if format is nil.
+func NewTextRecorder(w io.Writer, format func(data []byte) string) *TextRecorder {
+	if format == nil {
+		format = hex.EncodeToString
+	}
+	return &TextRecorder{
+		w:      w,
+		format: format,
+	}
+}
+
+func (tr *TextRecorder) Record(f *Frame) error {
+	tr.Lock()
+	defer tr.Unlock()
+
+	b := tr.buf[:0]
+	b = f.Time.AppendFormat(b, time.RFC3339Nano)
+	b = append(b, ' ')
+	b = append(b, f.Direction.String()...)
+	b = append(b, ' ')
+	b = appendAddr(b, f.Source())
+	b = append(b, "->"...)
+	b = appendAddr(b, f.Destination())
+	b = append(b, " len="...)
+	b = strconv.AppendInt(b, int64(len(f.Data)), 10)
+	b = append(b, ' ')
+	b = append(b, tr.format(f.Data)...)
+	b = append(b, '\n')
+	tr.buf = b
+
+	_, err := tr.w.Write(b)
+	return err
+}
+
+func appendAddr(b []byte, addr interface{ String() string }) []byte {
+	if addr == nil {
+		return append(b, '-')
+	}
+	return append(b, addr.String()...)
+}
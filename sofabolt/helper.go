@@ -54,3 +54,10 @@ func IsDeadlineError(err error) bool {
 
 	return true
 }
+
+func remoteAddr(conn net.Conn) string {
+	if conn == nil || conn.RemoteAddr() == nil {
+		return ""
+	}
+	return conn.RemoteAddr().String()
+}
@@ -0,0 +1,309 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+// Package histogram implements a lock-free log-linear histogram in the style of
+// HdrHistogram: every power of two is split into 16 linear sub buckets, which
+// bounds the relative error of the recorded values to 1/16.
+package histogram
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	subBucketBits = 4
+	subBuckets    = 1 << subBucketBits
+	numBuckets    = (64 - subBucketBits) * subBuckets
+)
+
+type buckets [numBuckets]int64
+
+// Histogram records non-negative int64 values, e.g. nanoseconds or bytes.
+// The zero Histogram is empty and ready for use, the buckets are allocated by
+// the first value so the unused histograms stay small. A Histogram must not be
+// copied after first use.
+type Histogram struct {
+	count  int64
+	sum    int64
+	min    int64 // stored as min+1 so the zero value means empty
+	max    int64
+	counts unsafe.Pointer // *buckets
+}
+
+// load returns the buckets, nil if nothing was recorded.
+func (h *Histogram) load() *buckets {
+	return (*buckets)(atomic.LoadPointer(&h.counts))
+}
+
+// buckets returns the buckets and allocates them if they are absent.
+func (h *Histogram) buckets() *buckets {
+	if b := h.load(); b != nil {
+		return b
+	}
+
+	b := new(buckets)
+	if atomic.CompareAndSwapPointer(&h.counts, nil, unsafe.Pointer(b)) {
+		return b
+	}
+	return h.load()
+}
+
+func bucketIndex(v int64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+
+	u := uint64(v)
+	shift := bits.Len64(u) - subBucketBits - 1
+	return (shift+1)*subBuckets + int(u>>uint(shift)) - subBuckets
+}
+
+// bucketUpperBound returns the highest value equivalent to the bucket i.
+func bucketUpperBound(i int) int64 {
+	if i < subBuckets {
+		return int64(i)
+	}
+
+	shift := uint(i/subBuckets - 1)
+	m := uint64(i%subBuckets + subBuckets)
+	upper := (m+1)<<shift - 1
+	if upper > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(upper)
+}
+
+// Record records the value v, the negative value is recorded as zero.
+func (h *Histogram) Record(v int64) {
+	if v < 0 {
+		v = 0
+	}
+
+	atomic.AddInt64(&h.buckets()[bucketIndex(v)], 1)
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, v)
+
+	for {
+		min := atomic.LoadInt64(&h.min)
+		if min != 0 && min-1 <= v {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&h.min, min, v+1) {
+			break
+		}
+	}
+
+	for {
+		max := atomic.LoadInt64(&h.max)
+		if max >= v {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&h.max, max, v) {
+			break
+		}
+	}
+}
+
+func (h *Histogram) GetCount() int64 { return atomic.LoadInt64(&h.count) }
+func (h *Histogram) GetSum() int64   { return atomic.LoadInt64(&h.sum) }
+
+// Merge adds the values recorded by o to h.
+func (h *Histogram) Merge(o *Histogram) {
+	s := o.Snapshot()
+	if s.Count == 0 {
+		return
+	}
+
+	b := h.buckets()
+	for i, n := range s.Counts {
+		if n > 0 {
+			atomic.AddInt64(&b[i], n)
+		}
+	}
+	atomic.AddInt64(&h.count, s.Count)
+	atomic.AddInt64(&h.sum, s.Sum)
+
+	for {
+		min := atomic.LoadInt64(&h.min)
+		if min != 0 && min-1 <= s.Min {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&h.min, min, s.Min+1) {
+			break
+		}
+	}
+
+	for {
+		max := atomic.LoadInt64(&h.max)
+		if max >= s.Max {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&h.max, max, s.Max) {
+			break
+		}
+	}
+}
+
+// Reset clears the histogram. It races with the concurrent Record.
+func (h *Histogram) Reset() {
+	if b := h.load(); b != nil {
+		for i := range b {
+			atomic.StoreInt64(&b[i], 0)
+		}
+	}
+	atomic.StoreInt64(&h.count, 0)
+	atomic.StoreInt64(&h.sum, 0)
+	atomic.StoreInt64(&h.min, 0)
+	atomic.StoreInt64(&h.max, 0)
+}
+
+// Snapshot returns a copy of the histogram. The copy is not atomic as a whole
+// but every counter is read atomically. The buckets are copied up to the last
+// non-empty one.
+func (h *Histogram) Snapshot() *Snapshot {
+	s := &Snapshot{
+		Count: atomic.LoadInt64(&h.count),
+		Sum:   atomic.LoadInt64(&h.sum),
+		Max:   atomic.LoadInt64(&h.max),
+	}
+
+	if min := atomic.LoadInt64(&h.min); min > 0 {
+		s.Min = min - 1
+	}
+
+	b := h.load()
+	if b == nil {
+		return s
+	}
+
+	last := -1
+	for i := range b {
+		if atomic.LoadInt64(&b[i]) > 0 {
+			last = i
+		}
+	}
+
+	if last >= 0 {
+		s.Counts = make([]int64, last+1)
+		for i := range s.Counts {
+			s.Counts[i] = atomic.LoadInt64(&b[i])
+		}
+	}
+
+	return s
+}
+
+// Snapshot is a point-in-time copy of a Histogram.
+type Snapshot struct {
+	Count  int64
+	Sum    int64
+	Min    int64
+	Max    int64
+	Counts []int64 // indexed by the bucket
+}
+
+func (s *Snapshot) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Sum) / float64(s.Count)
+}
+
+// Percentile returns the value at the percentile p in [0, 100].
+func (s *Snapshot) Percentile(p float64) int64 {
+	var total int64
+	for i := range s.Counts {
+		total += s.Counts[i]
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	if p <= 0 {
+		return s.Min
+	}
+
+	if p >= 100 {
+		return s.Max
+	}
+
+	rank := int64(math.Ceil(p / 100 * float64(total)))
+	var seen int64
+	for i := range s.Counts {
+		seen += s.Counts[i]
+		if seen >= rank {
+			v := bucketUpperBound(i)
+			if v > s.Max {
+				v = s.Max
+			}
+			if v < s.Min {
+				v = s.Min
+			}
+			return v
+		}
+	}
+
+	return s.Max
+}
+
+// Range calls fn with the upper bound and the count of every non-empty bucket.
+func (s *Snapshot) Range(fn func(upper int64, count int64)) {
+	for i := range s.Counts {
+		if s.Counts[i] > 0 {
+			fn(bucketUpperBound(i), s.Counts[i])
+		}
+	}
+}
+
+// Map holds the histograms keyed by a label, e.g. the response status.
+// The zero Map is empty and ready for use.
+type Map struct {
+	m sync.Map
+}
+
+// Get returns the histogram of key and allocates it if it's absent.
+func (m *Map) Get(key uint64) *Histogram {
+	if h, ok := m.m.Load(key); ok {
+		return h.(*Histogram)
+	}
+	h, _ := m.m.LoadOrStore(key, &Histogram{})
+	return h.(*Histogram)
+}
+
+// Load returns the histogram of key if it's present.
+func (m *Map) Load(key uint64) (*Histogram, bool) {
+	h, ok := m.m.Load(key)
+	if !ok {
+		return nil, false
+	}
+	return h.(*Histogram), true
+}
+
+func (m *Map) Range(fn func(key uint64, h *Histogram) bool) {
+	m.m.Range(func(k, v interface{}) bool {
+		return fn(k.(uint64), v.(*Histogram))
+	})
+}
+
+// Merge adds the histograms of o to m.
+func (m *Map) Merge(o *Map) {
+	o.Range(func(key uint64, h *Histogram) bool {
+		m.Get(key).Merge(h)
+		return true
+	})
+}
@@ -0,0 +1,101 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package histogram
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramBucket(t *testing.T) {
+	prev := -1
+	for _, v := range []int64{0, 1, 15, 16, 17, 31, 32, 33, 1000, 1 << 40, math.MaxInt64} {
+		i := bucketIndex(v)
+		require.Less(t, i, numBuckets)
+		require.GreaterOrEqual(t, i, prev)
+		require.GreaterOrEqual(t, bucketUpperBound(i), v)
+		// relative error is bounded by 1/16
+		require.LessOrEqual(t, float64(bucketUpperBound(i)-v), float64(v)/subBuckets)
+		prev = i
+	}
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	var h Histogram
+	for i := int64(1); i <= 1000; i++ {
+		h.Record(i)
+	}
+
+	s := h.Snapshot()
+	require.Equal(t, int64(1000), s.Count)
+	require.Equal(t, int64(1), s.Min)
+	require.Equal(t, int64(1000), s.Max)
+	require.Equal(t, 500.5, s.Mean())
+	require.InDelta(t, 500, s.Percentile(50), 500/16)
+	require.InDelta(t, 990, s.Percentile(99), 990/16)
+	require.Equal(t, int64(1000), s.Percentile(100))
+	require.Equal(t, int64(1), s.Percentile(0))
+}
+
+func TestHistogramConcurrent(t *testing.T) {
+	var (
+		h  Histogram
+		m  Map
+		wg sync.WaitGroup
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				h.Record(int64(j))
+				m.Get(uint64(i % 2)).Record(int64(j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(8000), h.GetCount())
+	require.Equal(t, int64(0), h.Snapshot().Min)
+	require.Equal(t, int64(999), h.Snapshot().Max)
+
+	var merged Histogram
+	m.Range(func(key uint64, h *Histogram) bool {
+		require.Equal(t, int64(4000), h.GetCount())
+		merged.Merge(h)
+		return true
+	})
+	require.Equal(t, h.Snapshot().Counts, merged.Snapshot().Counts)
+}
+
+func TestHistogramLazy(t *testing.T) {
+	var h Histogram
+	require.Nil(t, h.load())
+	require.Nil(t, h.Snapshot().Counts)
+	require.Equal(t, int64(0), h.Snapshot().Percentile(50))
+
+	h.Merge(&Histogram{})
+	require.Nil(t, h.load())
+
+	h.Record(20)
+	require.NotNil(t, h.load())
+	require.Len(t, h.Snapshot().Counts, bucketIndex(20)+1)
+
+	h.Reset()
+	require.Nil(t, h.Snapshot().Counts)
+}
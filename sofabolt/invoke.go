@@ -0,0 +1,174 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+const headerProtocol = "protocol"
+
+// Invoke calls method of the SOFARPC service (the unique name, e.g. "com.alipay.FooService:1.0")
+// with args and returns the app response. The timeout is taken from the deadline of ctx,
+// and ctx.Err() is returned once ctx is done.
+// Use RegisterSofaClass to decode the app response of a java class to a typed value.
+// The error responded by the remote is a *RemoteError.
+func (c *Client) Invoke(ctx context.Context, service, method string, args ...interface{}) (interface{}, error) {
+	timeout, err := contextTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+
+	req.SetContext(ctx)
+	req.SetTimeout(uint32(timeout / time.Millisecond))
+	err = req.SetSofaRequest(&SofaRequest{
+		TargetServiceUniqueName: service,
+		MethodName:              method,
+		Args:                    args,
+	})
+	if err != nil {
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+		return nil, err
+	}
+	req.GetRPCHeaders().SetProtocol("bolt")
+
+	err = c.doContext(ctx, req, res, timeout)
+	if isAbandoned(err) { // the request may be still referenced, let gc handle it.
+		return nil, err
+	}
+
+	defer func() {
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+	}()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.GetStatus() != StatusSuccess {
+		return nil, newStatusRemoteError(res)
+	}
+
+	var sr SofaResponse
+	if err = res.GetSofaResponse(&sr); err != nil {
+		return nil, err
+	}
+
+	if re := newSofaRemoteError(&sr); re != nil {
+		return nil, re
+	}
+
+	return sr.AppResponse, nil
+}
+
+// InvokeCodec calls method of the service with args and decodes the app response
+// to reply which is a pointer. The args and reply are encoded as the hessian
+// SofaRequest and SofaResponse if codec is CodecHessian2, or by the serializer
+// registered by RegisterSerializer otherwise. The timeout is taken from the
+// deadline of ctx, and ctx.Err() is returned once ctx is done. The error
+// responded by the remote is a *RemoteError.
+func (c *Client) InvokeCodec(ctx context.Context, codec Codec, service, method string,
+	reply interface{}, args ...interface{}) error {
+	if codec == CodecHessian2 {
+		v, err := c.Invoke(ctx, service, method, args...)
+		if err != nil || reply == nil {
+			return err
+		}
+		rv := reflect.ValueOf(reply)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return ErrSerializerUnsupported
+		}
+		return assignValue(rv.Elem(), v)
+	}
+
+	s, ok := GetSerializer(codec)
+	if !ok {
+		return ErrSerializerNotFound
+	}
+
+	timeout, err := contextTimeout(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+
+	req.SetContext(ctx)
+	req.SetTimeout(uint32(timeout / time.Millisecond))
+	req.command.content, err = marshalArgs(s, req.command.content[:0], args)
+	if err != nil {
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+		return err
+	}
+	req.SetCodec(codec).SetClassString(ClassRequest)
+	if codec == CodecJSON {
+		req.GetHeaders().Set("content-type", ContentTypeJSON)
+	}
+	req.setSofaHeaders("", service, method)
+	req.GetRPCHeaders().SetProtocol("bolt")
+
+	err = c.doContext(ctx, req, res, timeout)
+	if isAbandoned(err) { // the request may be still referenced, let gc handle it.
+		return err
+	}
+
+	defer func() {
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	if res.GetStatus() != StatusSuccess {
+		return newStatusRemoteError(res)
+	}
+
+	if re := newHeaderRemoteError(res); re != nil {
+		return re
+	}
+
+	if reply == nil || len(res.GetContent()) == 0 {
+		return nil
+	}
+
+	return res.GetBody(reply)
+}
+
+// contextTimeout returns the timeout of ctx, 0 if ctx has no deadline.
+func contextTimeout(ctx context.Context) (time.Duration, error) {
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+		if timeout <= 0 {
+			return 0, ErrClientTimeout
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return timeout, nil
+}
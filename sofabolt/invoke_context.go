@@ -23,6 +23,9 @@ type InvokeContext struct {
 	noCopy   noCopy
 	timeout  time.Duration
 	created  time.Time
+	sent     int64 // unix nano, may be loaded by the read goroutine
+	sending  int64
+	size     int
 	req      *Request
 	res      *Response
 	ireslock sync.Mutex
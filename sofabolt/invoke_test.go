@@ -0,0 +1,119 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sofastack/sofa-hessian-go/javaobject"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientInvoke(t *testing.T) {
+	blocked := make(chan struct{})
+	defer close(blocked)
+
+	p0, p1 := net.Pipe()
+	srv, err := NewServer(WithServerHandler(HandlerFunc(func(rw ResponseWriter, r *Request) {
+		var sr SofaRequest
+		if err := r.GetSofaRequest(&sr); err != nil {
+			rw.GetResponse().SetStatus(StatusServerDeseralException)
+			rw.Write()
+			return
+		}
+
+		require.Equal(t, "bolt", r.GetHeaders().Get(headerProtocol))
+		require.Equal(t, sr.TargetServiceUniqueName, r.GetHeaders().Get(headerTargetService))
+		require.Equal(t, sr.MethodName, r.GetHeaders().Get(headerMethodName))
+
+		res := SofaResponse{}
+		switch sr.MethodName {
+		case "echo":
+			res.AppResponse = sr.Args[0]
+		case "fail":
+			res.IsError = true
+			res.ErrorMsg = "failed"
+		case "throw":
+			res.AppResponse = &javaobject.SofaRPCServerException{
+				DetailMessage: "thrown",
+				StackTrace: javaobject.JavaLangStackTraceElements{
+					{DeclaringClass: "com.alipay.test.UserService", MethodName: "throw"},
+				},
+			}
+		case "status":
+			rw.GetResponse().SetStatus(StatusNoProcessor)
+			rw.Write()
+			return
+		case "block":
+			<-blocked
+		}
+
+		rw.GetResponse().SetSofaResponse(&res)
+		rw.Write()
+	})))
+	require.Nil(t, err)
+
+	go func() {
+		srv.ServeConn(p1)
+	}()
+
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	v, err := c.Invoke(ctx, "com.alipay.test.UserService:1.0", "echo", &sofaTestUser{Name: "foo", Age: 1})
+	require.Nil(t, err)
+	require.Equal(t, &sofaTestUser{Name: "foo", Age: 1}, v)
+
+	v, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "echo", "hello")
+	require.Nil(t, err)
+	require.Equal(t, "hello", v)
+
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "fail")
+	require.Equal(t, &RemoteError{Status: StatusServerException, Message: "failed"}, err)
+	require.True(t, errors.Is(err, ErrRemoteServerException))
+
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "throw")
+	require.Equal(t, &RemoteError{
+		Status:  StatusServerException,
+		Class:   "com.alipay.remoting.rpc.exception.RpcServerException",
+		Message: "thrown",
+		StackTrace: javaobject.JavaLangStackTraceElements{
+			{DeclaringClass: "com.alipay.test.UserService", MethodName: "throw"},
+		},
+	}, err)
+
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "status")
+	require.Equal(t, &RemoteError{Status: StatusNoProcessor}, err)
+	require.True(t, errors.Is(err, ErrRemoteNoProcessor))
+	require.False(t, errors.Is(err, ErrRemoteServerException))
+	require.Equal(t, "sofabolt: remote no-processor", err.Error())
+
+	cancel()
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "echo", "hello")
+	require.Equal(t, context.Canceled, err)
+
+	// no deadline, the call is given up once ctx is canceled
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "block")
+	require.Equal(t, context.Canceled, err)
+}
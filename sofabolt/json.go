@@ -0,0 +1,66 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+const ContentTypeJSON = "application/json"
+
+var ErrJSONMismatchCodec = errors.New("sofabolt: codec is not json")
+
+// SetJSON marshals v to the content and sets the codec to CodecJSON
+// and the content-type header to application/json.
+func (c *Request) SetJSON(v interface{}) error {
+	if err := c.SetCodec(CodecJSON).SetBody(v); err != nil {
+		return err
+	}
+	c.GetHeaders().Set("content-type", ContentTypeJSON)
+	return nil
+}
+
+// GetJSON unmarshals the content to v.
+func (c *Request) GetJSON(v interface{}) error {
+	if c.GetCodec() != CodecJSON {
+		return ErrJSONMismatchCodec
+	}
+	return json.Unmarshal(c.GetContent(), v)
+}
+
+// SetJSON marshals v to the content and sets the codec to CodecJSON
+// and the content-type header to application/json.
+func (c *Response) SetJSON(v interface{}) error {
+	if err := c.SetCodec(CodecJSON).SetBody(v); err != nil {
+		return err
+	}
+	c.GetHeaders().Set("content-type", ContentTypeJSON)
+	return nil
+}
+
+// GetJSON unmarshals the content to v.
+func (c *Response) GetJSON(v interface{}) error {
+	if c.GetCodec() != CodecJSON {
+		return ErrJSONMismatchCodec
+	}
+	return json.Unmarshal(c.GetContent(), v)
+}
+
+// InvokeJSON calls method of the service with args encoded as a JSON array and
+// unmarshals the JSON app response to reply, see Client.InvokeCodec.
+func (c *Client) InvokeJSON(ctx context.Context, service, method string, reply interface{}, args ...interface{}) error {
+	return c.InvokeCodec(ctx, CodecJSON, service, method, reply, args...)
+}
@@ -0,0 +1,98 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecString(t *testing.T) {
+	require.Equal(t, "hessian", CodecHessian.String())
+	require.Equal(t, "hessian2", CodecHessian2.String())
+	require.Equal(t, "protobuf", CodecProtobuf.String())
+	require.Equal(t, "json", CodecJSON.String())
+	require.Equal(t, "tbhessian2", CodecTBRemotingHessian2.String())
+	require.Equal(t, "unknown", Codec(100).String())
+}
+
+func TestJSON(t *testing.T) {
+	var req Request
+	require.Nil(t, req.SetJSON(map[string]int{"a": 1}))
+	require.Equal(t, CodecJSON, req.GetCodec())
+	require.Equal(t, ContentTypeJSON, req.GetHeaders().Get("content-type"))
+	require.Equal(t, `{"a":1}`, string(req.GetContent()))
+
+	var m map[string]int
+	require.Nil(t, req.GetJSON(&m))
+	require.Equal(t, map[string]int{"a": 1}, m)
+
+	var res Response
+	require.Nil(t, res.SetJSON(&sofaTestUser{Name: "foo", Age: 1}))
+	require.Equal(t, ContentTypeJSON, res.GetHeaders().Get("content-type"))
+
+	var u sofaTestUser
+	require.Nil(t, res.GetJSON(&u))
+	require.Equal(t, sofaTestUser{Name: "foo", Age: 1}, u)
+
+	res.SetCodec(CodecHessian2)
+	require.Equal(t, ErrJSONMismatchCodec, res.GetJSON(&u))
+}
+
+func TestClientInvokeJSON(t *testing.T) {
+	p0, p1 := net.Pipe()
+	srv, err := NewServer(WithServerHandler(HandlerFunc(func(rw ResponseWriter, r *Request) {
+		rw.GetResponse().SetStatus(StatusNoProcessor)
+		rw.Write()
+	})))
+	require.Nil(t, err)
+	require.Nil(t, srv.RegisterService("com.alipay.test.UserService:1.0", &userService{}))
+
+	go func() {
+		srv.ServeConn(p1)
+	}()
+
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var u sofaTestUser
+	require.Nil(t, c.InvokeJSON(ctx, "com.alipay.test.UserService:1.0", "echo", &u, &sofaTestUser{Name: "foo", Age: 1}))
+	require.Equal(t, sofaTestUser{Name: "foo", Age: 1}, u)
+
+	var n int
+	require.Nil(t, c.InvokeJSON(ctx, "com.alipay.test.UserService:1.0", "add", &n, 1, 2))
+	require.Equal(t, 3, n)
+
+	var s string
+	require.Nil(t, c.InvokeJSON(ctx, "com.alipay.test.UserService:1.0", "join", &s, []string{"a", "b"}, ","))
+	require.Equal(t, "a,b", s)
+
+	err = c.InvokeJSON(ctx, "com.alipay.test.UserService:1.0", "fail", nil)
+	require.Equal(t, &RemoteError{Status: StatusServerException, Message: "failed"}, err)
+
+	err = c.InvokeJSON(ctx, "com.alipay.test.UserService:1.0", "add", &n, 1)
+	require.True(t, errors.Is(err, ErrRemoteServerDeserialException))
+
+	err = c.InvokeJSON(ctx, "com.alipay.test.UserService:1.0", "add", &n, "a", "b")
+	require.True(t, errors.Is(err, ErrRemoteServerDeserialException))
+}
@@ -15,23 +15,46 @@ package sofabolt
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
     "net/http"
+	"strconv"
 	"sync"
 	"time"
 
-	jsoniter "github.com/json-iterator/go"
 	sofalogger "github.com/sofastack/sofa-common-go/logger"
 )
 
+// KeepAliverDialer creates a client connected to addr for KeepAliver.GetOrDial.
+type KeepAliverDialer interface {
+	Dial(ctx context.Context, tls bool, addr string) (*Client, error)
+}
+
+type KeepAliverDialerFunc func(ctx context.Context, tls bool, addr string) (*Client, error)
+
+func (f KeepAliverDialerFunc) Dial(ctx context.Context, tls bool, addr string) (*Client, error) {
+	return f(ctx, tls, addr)
+}
+
 type KeepAliverOptions struct {
-	Context           context.Context `json:"-"`
-	MaClientConnUsed  int             `json:"max_client_used"`
-	MinClientInPool   int             `json:"min_clinet_in_pool"`
-	HeartbeatInterval time.Duration   `json:"heartbeat_interval"`
-	HeartbeatTimeout  time.Duration   `json:"heartbeat_timeout"`
-	CleanupInterval   time.Duration   `json:"cleanup_interval"`
-	CleanupMaxChecks  int             `json:"cleanup_max_checks"`
+	Context           context.Context  `json:"-"`
+	Dialer            KeepAliverDialer `json:"-"`
+	Picker            Picker           `json:"-"`
+	MaClientConnUsed  int              `json:"max_client_used"`
+	MinClientInPool   int              `json:"min_clinet_in_pool"`
+	MaxClientInPool   int              `json:"max_client_in_pool"`
+	HeartbeatInterval time.Duration    `json:"heartbeat_interval"`
+	HeartbeatTimeout  time.Duration    `json:"heartbeat_timeout"`
+	DialTimeout       time.Duration    `json:"dial_timeout"`
+	CleanupInterval   time.Duration    `json:"cleanup_interval"`
+	CleanupMaxChecks  int              `json:"cleanup_max_checks"`
+}
+
+type keepAliverDialCall struct {
+	done   chan struct{}
+	client *Client
+	err    error
 }
 
 type KeepAliver struct {
@@ -41,12 +64,18 @@ type KeepAliver struct {
 	tls     PoolMap
 	// dying holds the clients will dies
 	dying sync.Map
+	// dialing coalesces the concurrent dials to the same address
+	dialLock sync.Mutex
+	dialing  map[string]*keepAliverDialCall
+	// warming holds the addresses which are pre-warming in background
+	warming sync.Map
 }
 
 func NewKeepAliver(o *KeepAliverOptions, logger sofalogger.Logger) (*KeepAliver, error) {
 	ka := &KeepAliver{
 		logger:  logger,
 		options: o,
+		dialing: make(map[string]*keepAliverDialCall, 64),
 	}
 
 	if err := ka.polyfill(); err != nil {
@@ -77,10 +106,22 @@ func (ca *KeepAliver) polyfill() error {
 		ca.options.HeartbeatTimeout = 5 * time.Second
 	}
 
+	if ca.options.DialTimeout == 0 {
+		ca.options.DialTimeout = 5 * time.Second
+	}
+
 	if ca.options.Context == nil {
 		ca.options.Context = context.TODO()
 	}
 
+	if ca.options.Picker == nil {
+		ca.options.Picker = RoundRobinPicker
+	}
+
+	if ca.options.MaxClientInPool > 0 && ca.options.MinClientInPool > ca.options.MaxClientInPool {
+		return errors.New("sofabolt: keepaliver min clients in pool is greater than max")
+	}
+
 	return nil
 }
 
@@ -141,15 +182,31 @@ func (ca *KeepAliver) doHeartbeat(ctx context.Context) {
 		ReleaseResponse(res)
 	}()
 
-	clientChecker := func(scheme string, address string, p *Pool, t time.Time) bool {
+	clientChecker := func(tls bool, address string, p *Pool, t time.Time) bool {
+		scheme := "raw"
+		if tls {
+			scheme = "tls"
+		}
+
 		// send heartbeat and recive broken client
 		clients := p.copyClients()
 		// broken client list
 		brokens := make([]*Client, 0)
 		for _, client := range clients {
+			if client.Closed() {
+				brokens = append(brokens, client)
+				continue
+			}
+
 			if t.Unix()-client.GetMetrics().GetLasted() >= int64(heartbeatInterval.Seconds()) {
 				// send heartbeat
-				if err := client.DoTimeout(req, res, heartbeatTimeout); err != nil {
+				started := time.Now()
+				err := client.DoTimeout(req, res, heartbeatTimeout)
+				if err == nil && res.GetStatus() != StatusSuccess {
+					err = newStatusRemoteError(res)
+				}
+				p.GetHealth().onHeartbeat(t, time.Since(started), err)
+				if err != nil {
 					// send heartbeat error, or server return error
 					// this means client was broken
 					brokens = append(brokens, client)
@@ -159,13 +216,19 @@ func (ca *KeepAliver) doHeartbeat(ctx context.Context) {
 			}
 		}
 
+		if len(brokens) == 0 {
+			return true
+		}
+
 		// delete broken clients
 		p.DeleteClients(brokens)
 
-		// close broken clients
+		// broken clients may still be referenced: let cleanup close them
 		for _, client := range brokens {
-			_ = client.Close()
+			ca.GracefullyClose(client)
 		}
+
+		ca.replace(tls, address, p, len(brokens))
 		return true
 	}
 
@@ -179,16 +242,100 @@ func (ca *KeepAliver) doHeartbeat(ctx context.Context) {
 		case t := <-timer.C:
 			ca.logger.Infof("try send bolt heartbeat")
 			ca.raw.Range(func(addr string, pool *Pool) bool {
-				return clientChecker("raw", addr, pool, t)
+				return clientChecker(false, addr, pool, t)
 			})
 
-			ca.raw.Range(func(addr string, pool *Pool) bool {
-				return clientChecker("tls", addr, pool, t)
+			ca.tls.Range(func(addr string, pool *Pool) bool {
+				return clientChecker(true, addr, pool, t)
 			})
 		}
 	}
 }
 
+// replace dials n clients in background to replace the evicted ones. It gives up
+// once the pool is drained or evicted, rather than recreating it.
+func (ca *KeepAliver) replace(tls bool, addr string, p *Pool, n int) {
+	if ca.options.Dialer == nil {
+		return
+	}
+
+	m := ca.getPoolMap(tls)
+	go func() {
+		for i := 0; i < n; i++ {
+			if max := ca.options.MaxClientInPool; max > 0 && p.Size() >= max {
+				return
+			}
+
+			if !ca.isPoolOf(m, addr, p) {
+				return
+			}
+
+			client, err := ca.options.Dialer.Dial(ca.options.Context, tls, addr)
+			if err != nil {
+				ca.logger.Errorf("failed to replace bolt client address=%s error=%+v", addr, err)
+				return
+			}
+
+			if !p.pushLimited(client, ca.options.MaxClientInPool) {
+				// nolint
+				client.Close()
+				return
+			}
+			if !ca.isPoolOf(m, addr, p) { // drained in the meantime
+				p.Delete(client)
+				// nolint
+				client.Close()
+				return
+			}
+			p.GetHealth().addReplacements(1)
+		}
+	}()
+}
+
+// isPoolOf reports whether p is still the pool of addr.
+func (ca *KeepAliver) isPoolOf(m *PoolMap, addr string, p *Pool) bool {
+	actual, ok := m.Load(addr)
+	return ok && actual == p
+}
+
+// Drain removes the pool of addr and gracefully closes its clients: the
+// referenced clients are closed by cleanup once they are idle.
+func (ca *KeepAliver) Drain(tls bool, addr string) int {
+	m := ca.getPoolMap(tls)
+	p, ok := m.Load(addr)
+	if !ok {
+		return 0
+	}
+	m.Delete(addr)
+
+	clients := p.copyClients()
+	p.DeleteClients(clients)
+	for _, client := range clients {
+		ca.GracefullyClose(client)
+	}
+
+	return len(clients)
+}
+
+// Evict removes the pool of addr and closes its clients immediately.
+func (ca *KeepAliver) Evict(tls bool, addr string) int {
+	m := ca.getPoolMap(tls)
+	p, ok := m.Load(addr)
+	if !ok {
+		return 0
+	}
+	m.Delete(addr)
+
+	clients := p.copyClients()
+	p.DeleteClients(clients)
+	for _, client := range clients {
+		err := client.Close()
+		ca.logger.Infof("evict bolt client address=%s conn=%+v err=%+v", addr, client.GetConn(), err)
+	}
+
+	return len(clients)
+}
+
 func (ca *KeepAliver) Put(tls bool, force bool, addr string, client *Client) bool {
 	if tls {
 		return ca.put(&ca.tls, force, addr, client)
@@ -200,7 +347,7 @@ func (ca *KeepAliver) put(m *PoolMap, force bool, addr string, client *Client) b
 	var (
 		loaded bool
 		actual *Pool
-		p      = NewPool()
+		p      = NewPoolWithPicker(ca.options.Picker)
 	)
 
 	p.Push(client)
@@ -214,6 +361,11 @@ func (ca *KeepAliver) put(m *PoolMap, force bool, addr string, client *Client) b
 		return false
 	}
 
+	if !force && loaded && ca.options.MaxClientInPool > 0 &&
+		actual.Size() >= ca.options.MaxClientInPool {
+		return false
+	}
+
 	if force || (ca.options.MinClientInPool > 0 && actual.Size() <= ca.options.MinClientInPool) {
 		if loaded {
 			actual.Push(client)
@@ -258,6 +410,193 @@ func (t *KeepAliver) get(m *PoolMap, addr string) (*Client, bool) {
 	return c, true
 }
 
+// GetOrDial returns a pooled client of addr or dials a new one if the pool is
+// empty. Concurrent dials to the same address are coalesced into one which is
+// bounded by DialTimeout rather than ctx, every caller gives up waiting it once
+// its own ctx is done. The pool is filled up to MinClientInPool in background.
+// ErrKeepAliverPoolFull is returned if the dialed client cannot be pooled
+// because of MaxClientInPool and none of the pooled clients is alive.
+func (ca *KeepAliver) GetOrDial(ctx context.Context, tls bool, addr string) (*Client, error) {
+	if ca.options.Dialer == nil {
+		return nil, ErrKeepAliverNilDialer
+	}
+
+	m := ca.getPoolMap(tls)
+
+	client, ok := ca.getAlive(m, addr)
+	if !ok {
+		var err error
+		client, err = ca.dial(ctx, m, tls, addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ca.options.MinClientInPool > 0 && ca.getPoolSize(m, addr) < ca.options.MinClientInPool {
+		ca.prewarm(m, tls, addr)
+	}
+
+	return client, nil
+}
+
+func (ca *KeepAliver) getPoolMap(tls bool) *PoolMap {
+	if tls {
+		return &ca.tls
+	}
+	return &ca.raw
+}
+
+func (ca *KeepAliver) getPoolSize(m *PoolMap, addr string) int {
+	p, ok := m.Load(addr)
+	if !ok {
+		return 0
+	}
+	return p.Size()
+}
+
+func (ca *KeepAliver) dial(ctx context.Context, m *PoolMap, tls bool, addr string) (*Client, error) {
+	key := addr
+	if tls {
+		key = "tls://" + addr
+	}
+
+	ca.dialLock.Lock()
+	call, ok := ca.dialing[key]
+	if !ok {
+		call = &keepAliverDialCall{done: make(chan struct{})}
+		ca.dialing[key] = call
+
+		// the dial is shared: do not bind it to the context of any caller
+		go func() {
+			dctx, cancel := context.WithTimeout(ca.options.Context, ca.options.DialTimeout)
+			call.client, call.err = ca.doDial(dctx, m, tls, addr)
+			cancel()
+			close(call.done)
+
+			ca.dialLock.Lock()
+			delete(ca.dialing, key)
+			ca.dialLock.Unlock()
+		}()
+	}
+	ca.dialLock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.client, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (ca *KeepAliver) doDial(ctx context.Context, m *PoolMap, tls bool, addr string) (*Client, error) {
+	if max := ca.options.MaxClientInPool; max > 0 && ca.getPoolSize(m, addr) >= max {
+		// someone filled the pool in the meantime
+		if client, ok := ca.getAlive(m, addr); ok {
+			return client, nil
+		}
+	}
+
+	client, err := ca.options.Dialer.Dial(ctx, tls, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; !ca.putLimited(m, addr, client); i++ {
+		// the pool is filled in the meantime
+		if pooled, ok := ca.getAlive(m, addr); ok {
+			// nolint
+			client.Close()
+			return pooled, nil
+		}
+
+		// the closed clients are deleted by getAlive: try once more, but
+		// never hand out a client which is not pooled
+		if i > 0 {
+			// nolint
+			client.Close()
+			return nil, ErrKeepAliverPoolFull
+		}
+	}
+
+	return client, nil
+}
+
+// getAlive is get but deletes the closed clients from the pool.
+func (ca *KeepAliver) getAlive(m *PoolMap, addr string) (*Client, bool) {
+	client, ok := ca.get(m, addr)
+	for ok && client.Closed() {
+		ca.del(m, addr, client)
+		client, ok = ca.get(m, addr)
+	}
+	return client, ok
+}
+
+// putLimited pools the client unless the pool of addr has MaxClientInPool clients.
+func (ca *KeepAliver) putLimited(m *PoolMap, addr string, client *Client) bool {
+	p, ok := m.Load(addr)
+	if !ok {
+		p, _ = m.LoadOrStore(addr, NewPoolWithPicker(ca.options.Picker))
+	}
+	return p.pushLimited(client, ca.options.MaxClientInPool)
+}
+
+func (ca *KeepAliver) prewarm(m *PoolMap, tls bool, addr string) {
+	key := addr
+	if tls {
+		key = "tls://" + addr
+	}
+
+	if _, loaded := ca.warming.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	go func() {
+		defer ca.warming.Delete(key)
+
+		ctx := ca.options.Context
+		for ca.getPoolSize(m, addr) < ca.options.MinClientInPool {
+			if max := ca.options.MaxClientInPool; max > 0 && ca.getPoolSize(m, addr) >= max {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			client, err := ca.options.Dialer.Dial(ctx, tls, addr)
+			if err != nil {
+				ca.logger.Errorf("failed to prewarm bolt client address=%s error=%+v", addr, err)
+				return
+			}
+
+			if !ca.putLimited(m, addr, client) {
+				// nolint
+				client.Close()
+				return
+			}
+		}
+	}()
+}
+
+// Range calls fn for every pool until fn returns false.
+func (ca *KeepAliver) Range(fn func(tls bool, addr string, p *Pool) bool) {
+	next := true
+	ca.raw.Range(func(addr string, p *Pool) bool {
+		next = fn(false, addr, p)
+		return next
+	})
+
+	if !next {
+		return
+	}
+
+	ca.tls.Range(func(addr string, p *Pool) bool {
+		return fn(true, addr, p)
+	})
+}
+
 func (ca *KeepAliver) Del(tls bool, address string, client *Client) bool {
 	if tls {
 		return ca.del(&ca.tls, address, client)
@@ -295,7 +634,16 @@ func (k *KeepAliver) GracefullyClose(client *Client) {
 	}
 }
 
+// ServeHTTP reports the status of pools.
+//
+// POST with the query action=drain|evict, address=<addr> and tls=<bool>
+// drains or evicts the pool of the address.
 func (k *KeepAliver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		k.serveAction(rw, r)
+		return
+	}
+
 	type clientStatus struct {
 		Connection string `json:"connection"`
 		Used       int64  `json:"used"`
@@ -361,5 +709,49 @@ func (k *KeepAliver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	s.Elapsed = time.Since(started).String()
 
 	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
-	_ = jsoniter.NewEncoder(rw).Encode(s)
+	_ = json.NewEncoder(rw).Encode(s)
+}
+
+func (k *KeepAliver) serveAction(rw http.ResponseWriter, r *http.Request) {
+	type result struct {
+		Action  string `json:"action"`
+		Address string `json:"address"`
+		TLS     bool   `json:"tls"`
+		Clients int    `json:"clients"`
+	}
+
+	query := r.URL.Query()
+	res := result{
+		Action:  query.Get("action"),
+		Address: query.Get("address"),
+	}
+
+	if res.Address == "" {
+		http.Error(rw, "address cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	if v := query.Get("tls"); v != "" {
+		var err error
+		if res.TLS, err = strconv.ParseBool(v); err != nil {
+			http.Error(rw, "malformed tls: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch res.Action {
+	case "drain":
+		res.Clients = k.Drain(res.TLS, res.Address)
+	case "evict":
+		res.Clients = k.Evict(res.TLS, res.Address)
+	default:
+		http.Error(rw, "unknown action: "+res.Action, http.StatusBadRequest)
+		return
+	}
+
+	k.logger.Infof("%s bolt pool address=%s tls=%t clients=%d",
+		res.Action, res.Address, res.TLS, res.Clients)
+
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(rw).Encode(res)
 }
@@ -0,0 +1,293 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sofalogger "github.com/sofastack/sofa-common-go/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeepAliverTestDialer(t *testing.T, dials *int32) KeepAliverDialer {
+	return KeepAliverDialerFunc(func(ctx context.Context, tls bool, addr string) (*Client, error) {
+		atomic.AddInt32(dials, 1)
+		time.Sleep(50 * time.Millisecond)
+		p0, _ := net.Pipe()
+		return NewClient(WithClientConn(p0))
+	})
+}
+
+func newKeepAliverTestLogger(t *testing.T) sofalogger.Logger {
+	logger, err := sofalogger.New(ioutil.Discard, sofalogger.NewConfig())
+	require.Nil(t, err)
+	return logger
+}
+
+func TestKeepAliverGetOrDialCoalesce(t *testing.T) {
+	var dials int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ka, err := NewKeepAliver(&KeepAliverOptions{
+		Context: ctx,
+		Dialer:  newKeepAliverTestDialer(t, &dials),
+	}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	var wg sync.WaitGroup
+	clients := make([]*Client, 16)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := ka.GetOrDial(context.Background(), false, "127.0.0.1:12200")
+			require.Nil(t, err)
+			clients[i] = c
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&dials))
+	for i := range clients {
+		require.Equal(t, clients[0], clients[i])
+	}
+
+	c, ok := ka.Get(false, "127.0.0.1:12200")
+	require.True(t, ok)
+	require.Equal(t, clients[0], c)
+}
+
+func TestKeepAliverGetOrDialSharedContext(t *testing.T) {
+	var dials int32
+	ka, err := NewKeepAliver(&KeepAliverOptions{
+		Dialer: KeepAliverDialerFunc(func(ctx context.Context, tls bool, addr string) (*Client, error) {
+			atomic.AddInt32(&dials, 1)
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			p0, _ := net.Pipe()
+			return NewClient(WithClientConn(p0))
+		}),
+	}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	// the first caller gives up, but the dial goes on for the others
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := ka.GetOrDial(ctx, false, "127.0.0.1:12200")
+		errCh <- err
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	c, err := ka.GetOrDial(context.Background(), false, "127.0.0.1:12200")
+	require.Nil(t, err)
+	require.NotNil(t, c)
+	require.Equal(t, context.DeadlineExceeded, <-errCh)
+	require.Equal(t, int32(1), atomic.LoadInt32(&dials))
+}
+
+func TestKeepAliverPutLimited(t *testing.T) {
+	ka, err := NewKeepAliver(&KeepAliverOptions{MaxClientInPool: 1}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	require.True(t, ka.putLimited(&ka.raw, "127.0.0.1:12200", &Client{}))
+	require.False(t, ka.putLimited(&ka.raw, "127.0.0.1:12200", &Client{}))
+	require.Equal(t, 1, ka.getPoolSize(&ka.raw, "127.0.0.1:12200"))
+}
+
+func TestKeepAliverGetOrDialPoolClosing(t *testing.T) {
+	var ka *KeepAliver
+	ka, err := NewKeepAliver(&KeepAliverOptions{
+		MaxClientInPool: 2,
+		Dialer: KeepAliverDialerFunc(func(ctx context.Context, tls bool, addr string) (*Client, error) {
+			// the pool is filled by the closing clients in the meantime
+			for i := 0; i < 2; i++ {
+				p0, _ := net.Pipe()
+				c, err := NewClient(WithClientConn(p0))
+				require.Nil(t, err)
+				require.Nil(t, c.Close())
+				require.True(t, ka.putLimited(&ka.raw, addr, c))
+			}
+			p0, _ := net.Pipe()
+			return NewClient(WithClientConn(p0))
+		}),
+	}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	c, err := ka.GetOrDial(context.Background(), false, "127.0.0.1:12200")
+	require.Nil(t, err)
+	require.False(t, c.Closed())
+
+	// the dialed client is pooled in place of the closing ones
+	p, ok := ka.raw.Load("127.0.0.1:12200")
+	require.True(t, ok)
+	require.Equal(t, []*Client{c}, p.copyClients())
+}
+
+func TestKeepAliverGetOrDialPrewarm(t *testing.T) {
+	var dials int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ka, err := NewKeepAliver(&KeepAliverOptions{
+		Context:         ctx,
+		Dialer:          newKeepAliverTestDialer(t, &dials),
+		MinClientInPool: 3,
+		MaxClientInPool: 3,
+	}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	_, err = ka.GetOrDial(context.Background(), true, "127.0.0.1:12200")
+	require.Nil(t, err)
+
+	require.Eventually(t, func() bool {
+		p, ok := ka.tls.Load("127.0.0.1:12200")
+		return ok && p.Size() == 3
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(3), atomic.LoadInt32(&dials))
+}
+
+func TestKeepAliverGetOrDialNilDialer(t *testing.T) {
+	ka, err := NewKeepAliver(&KeepAliverOptions{}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+	_, err = ka.GetOrDial(context.Background(), false, "127.0.0.1:12200")
+	require.Equal(t, ErrKeepAliverNilDialer, err)
+}
+
+func TestKeepAliverHeartbeatReplace(t *testing.T) {
+	var dials int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ka, err := NewKeepAliver(&KeepAliverOptions{
+		Context:           ctx,
+		Dialer:            newKeepAliverTestDialer(t, &dials),
+		HeartbeatInterval: 100 * time.Millisecond,
+		HeartbeatTimeout:  50 * time.Millisecond,
+	}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	// nobody answers the heartbeat
+	p0, _ := net.Pipe()
+	broken, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	require.True(t, ka.Put(false, true, "127.0.0.1:12200", broken))
+
+	require.Eventually(t, func() bool {
+		p, ok := ka.raw.Load("127.0.0.1:12200")
+		return ok && p.GetHealth().GetReplacements() >= 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	p, _ := ka.raw.Load("127.0.0.1:12200")
+	require.GreaterOrEqual(t, p.GetHealth().GetFailures(), int64(1))
+	require.NotEmpty(t, p.GetHealth().GetLastError())
+	require.True(t, broken.Closed())
+	for _, c := range p.copyClients() {
+		require.NotEqual(t, broken, c)
+	}
+}
+
+func TestKeepAliverHeartbeatStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ka, err := NewKeepAliver(&KeepAliverOptions{
+		Context:           ctx,
+		HeartbeatInterval: 100 * time.Millisecond,
+		HeartbeatTimeout:  50 * time.Millisecond,
+	}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	// the heartbeat is answered but not successful
+	p0, p1 := net.Pipe()
+	srv, err := NewServer(WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+		rw.GetResponse().SetStatus(StatusServerThreadPoolBusy)
+		rw.Write()
+	})))
+	require.Nil(t, err)
+	go srv.ServeConn(p1)
+
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	require.True(t, ka.Put(false, true, "127.0.0.1:12200", c))
+	p, _ := ka.raw.Load("127.0.0.1:12200")
+
+	require.Eventually(t, func() bool {
+		return p.GetHealth().GetFailures() >= 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, 0, p.Size())
+}
+
+func TestKeepAliverReplaceDrained(t *testing.T) {
+	var dials int32
+	ka, err := NewKeepAliver(&KeepAliverOptions{
+		Dialer: newKeepAliverTestDialer(t, &dials),
+	}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	p0, _ := net.Pipe()
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	require.True(t, ka.Put(false, true, "127.0.0.1:12200", c))
+	p, _ := ka.raw.Load("127.0.0.1:12200")
+
+	require.Equal(t, 1, ka.Drain(false, "127.0.0.1:12200"))
+	ka.replace(false, "127.0.0.1:12200", p, 2)
+
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, int32(0), atomic.LoadInt32(&dials))
+	_, ok := ka.raw.Load("127.0.0.1:12200")
+	require.False(t, ok)
+}
+
+func TestKeepAliverServeHTTPEvict(t *testing.T) {
+	ka, err := NewKeepAliver(&KeepAliverOptions{}, newKeepAliverTestLogger(t))
+	require.Nil(t, err)
+
+	p0, _ := net.Pipe()
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	require.True(t, ka.Put(true, true, "127.0.0.1:12200", c))
+
+	rw := httptest.NewRecorder()
+	ka.ServeHTTP(rw, httptest.NewRequest(http.MethodPost,
+		"/?action=evict&address=127.0.0.1:12200&tls=true", nil))
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Contains(t, rw.Body.String(), `"clients":1`)
+	require.True(t, c.Closed())
+
+	_, ok := ka.Get(true, "127.0.0.1:12200")
+	require.False(t, ok)
+
+	rw = httptest.NewRecorder()
+	ka.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/?action=unknown&address=a", nil))
+	require.Equal(t, http.StatusBadRequest, rw.Code)
+
+	rw = httptest.NewRecorder()
+	ka.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rw.Code)
+}
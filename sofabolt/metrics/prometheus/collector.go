@@ -0,0 +1,460 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+// Package prometheus exposes the metrics of sofabolt in the Prometheus format.
+//
+// Every metric is read from the metrics added to the Collector on collection,
+// the histograms of ServerMetrics and ClientMetrics are exported with the buckets
+// of the Collector.
+package prometheus
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sofastack/sofa-bolt-go/sofabolt"
+	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/asyncwriteconn"
+	"github.com/sofastack/sofa-bolt-go/sofabolt/histogram"
+)
+
+const (
+	// LabelOther replaces the label values exceeding the limit of the collector.
+	LabelOther = "other"
+
+	DefaultMaxAddresses = 256
+)
+
+var (
+	DefaultLatencyBuckets = []float64{
+		.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10,
+	}
+	DefaultSizeBuckets = prom.ExponentialBuckets(64, 4, 10)
+)
+
+// Collector collects the metrics of servers, clients, keepalivers and async
+// writers. It implements prometheus.Collector.
+type Collector struct {
+	sync.RWMutex
+	servers     map[string]*sofabolt.ServerMetrics
+	clients     map[string]*sofabolt.ClientMetrics
+	keepalivers map[string]*sofabolt.KeepAliver
+	writers     map[string]*asyncwriteconn.Metrics
+
+	options struct {
+		namespace      string
+		latencyBuckets []float64
+		sizeBuckets    []float64
+		maxAddresses   int
+	}
+
+	addresses *labelSet
+
+	server   serverDescs
+	client   clientDescs
+	pool     poolDescs
+	writer   writerDescs
+	registry *prom.Registry
+}
+
+type serverDescs struct {
+	bytesRead          *prom.Desc
+	bytesWrite         *prom.Desc
+	commands           *prom.Desc
+	pendingCommands    *prom.Desc
+	connections        *prom.Desc
+	pendingConnections *prom.Desc
+	latency            *prom.Desc
+	requestSize        *prom.Desc
+	responseSize       *prom.Desc
+}
+
+type clientDescs struct {
+	bytesRead        *prom.Desc
+	bytesWrite       *prom.Desc
+	commands         *prom.Desc
+	pendingCommands  *prom.Desc
+	pendingBytes     *prom.Desc
+	references       *prom.Desc
+	used             *prom.Desc
+	blockedCallbacks *prom.Desc
+	latency          *prom.Desc
+	requestSize      *prom.Desc
+	responseSize     *prom.Desc
+}
+
+type poolDescs struct {
+	clients      *prom.Desc
+	references   *prom.Desc
+	pending      *prom.Desc
+	heartbeats   *prom.Desc
+	failures     *prom.Desc
+	replacements *prom.Desc
+	lastRTT      *prom.Desc
+}
+
+type writerDescs struct {
+	commands        *prom.Desc
+	pendingCommands *prom.Desc
+	bytes           *prom.Desc
+}
+
+func NewCollector(options ...OptionSetter) *Collector {
+	c := &Collector{
+		servers:     make(map[string]*sofabolt.ServerMetrics, 4),
+		clients:     make(map[string]*sofabolt.ClientMetrics, 16),
+		keepalivers: make(map[string]*sofabolt.KeepAliver, 4),
+		writers:     make(map[string]*asyncwriteconn.Metrics, 16),
+	}
+
+	for _, option := range options {
+		option.Set(c)
+	}
+
+	c.polyfill()
+
+	return c
+}
+
+func (c *Collector) polyfill() {
+	if c.options.namespace == "" {
+		c.options.namespace = "sofabolt"
+	}
+
+	if c.options.latencyBuckets == nil {
+		c.options.latencyBuckets = DefaultLatencyBuckets
+	}
+
+	if c.options.sizeBuckets == nil {
+		c.options.sizeBuckets = DefaultSizeBuckets
+	}
+
+	if c.options.maxAddresses <= 0 {
+		c.options.maxAddresses = DefaultMaxAddresses
+	}
+
+	c.addresses = newLabelSet(c.options.maxAddresses)
+
+	ns := c.options.namespace
+	desc := func(subsystem, name, help string, labels ...string) *prom.Desc {
+		return prom.NewDesc(prom.BuildFQName(ns, subsystem, name), help, labels, nil)
+	}
+
+	c.server = serverDescs{
+		bytesRead:          desc("server", "read_bytes_total", "Bytes read by server.", "server"),
+		bytesWrite:         desc("server", "written_bytes_total", "Bytes written by server.", "server"),
+		commands:           desc("server", "commands_total", "Commands handled by server.", "server"),
+		pendingCommands:    desc("server", "pending_commands", "Commands in handling.", "server"),
+		connections:        desc("server", "connections_total", "Connections accepted by server.", "server"),
+		pendingConnections: desc("server", "pending_connections", "Connections in serving.", "server"),
+		latency:            desc("server", "request_duration_seconds", "Latency of requests handled by server.", "server", "status"),
+		requestSize:        desc("server", "request_size_bytes", "Size of requests read by server.", "server"),
+		responseSize:       desc("server", "response_size_bytes", "Size of responses written by server.", "server"),
+	}
+
+	c.client = clientDescs{
+		bytesRead:        desc("client", "read_bytes_total", "Bytes read by client.", "client"),
+		bytesWrite:       desc("client", "written_bytes_total", "Bytes written by client.", "client"),
+		commands:         desc("client", "commands_total", "Commands written by client.", "client"),
+		pendingCommands:  desc("client", "pending_commands", "Commands not flushed yet.", "client"),
+		pendingBytes:     desc("client", "pending_bytes", "Bytes of requests waiting for responses.", "client"),
+		references:       desc("client", "references", "Calls in flight.", "client"),
+		used:             desc("client", "used_total", "Calls sent by client.", "client"),
+		blockedCallbacks: desc("client", "blocked_callbacks_total", "Callbacks exceeded the block threshold.", "client"),
+		latency:          desc("client", "request_duration_seconds", "Round-trip latency of requests sent by client.", "client", "status"),
+		requestSize:      desc("client", "request_size_bytes", "Size of requests written by client.", "client"),
+		responseSize:     desc("client", "response_size_bytes", "Size of responses read by client.", "client"),
+	}
+
+	c.pool = poolDescs{
+		clients:      desc("pool", "clients", "Clients in pool.", "keepaliver", "scheme", "address"),
+		references:   desc("pool", "references", "Calls in flight of pool.", "keepaliver", "scheme", "address"),
+		pending:      desc("pool", "pending_commands", "Commands not flushed of pool.", "keepaliver", "scheme", "address"),
+		heartbeats:   desc("pool", "heartbeats_total", "Heartbeats sent.", "keepaliver", "scheme", "address"),
+		failures:     desc("pool", "heartbeat_failures_total", "Heartbeats failed.", "keepaliver", "scheme", "address"),
+		replacements: desc("pool", "replacements_total", "Broken clients replaced.", "keepaliver", "scheme", "address"),
+		lastRTT:      desc("pool", "heartbeat_rtt_seconds", "RTT of the last heartbeat.", "keepaliver", "scheme", "address"),
+	}
+
+	c.writer = writerDescs{
+		commands:        desc("writer", "commands_total", "Commands written by async writer.", "writer"),
+		pendingCommands: desc("writer", "pending_commands", "Commands not flushed by async writer.", "writer"),
+		bytes:           desc("writer", "written_bytes_total", "Bytes written by async writer.", "writer"),
+	}
+
+	c.registry = prom.NewRegistry()
+	c.registry.MustRegister(c)
+}
+
+func (c *Collector) AddServer(name string, sm *sofabolt.ServerMetrics) {
+	c.Lock()
+	c.servers[name] = sm
+	c.Unlock()
+}
+
+func (c *Collector) DelServer(name string) {
+	c.Lock()
+	delete(c.servers, name)
+	c.Unlock()
+}
+
+func (c *Collector) AddClient(name string, cm *sofabolt.ClientMetrics) {
+	c.Lock()
+	c.clients[name] = cm
+	c.Unlock()
+}
+
+func (c *Collector) DelClient(name string) {
+	c.Lock()
+	delete(c.clients, name)
+	c.Unlock()
+}
+
+func (c *Collector) AddKeepAliver(name string, ka *sofabolt.KeepAliver) {
+	c.Lock()
+	c.keepalivers[name] = ka
+	c.Unlock()
+}
+
+func (c *Collector) DelKeepAliver(name string) {
+	c.Lock()
+	delete(c.keepalivers, name)
+	c.Unlock()
+}
+
+func (c *Collector) AddAsyncWriter(name string, m *asyncwriteconn.Metrics) {
+	c.Lock()
+	c.writers[name] = m
+	c.Unlock()
+}
+
+func (c *Collector) DelAsyncWriter(name string) {
+	c.Lock()
+	delete(c.writers, name)
+	c.Unlock()
+}
+
+// Handler returns the http handler serves the metrics of the collector, it
+// is usually mounted on /metrics.
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+type poolStats struct {
+	clients      int
+	references   int64
+	pending      int64
+	heartbeats   int64
+	failures     int64
+	replacements int64
+	lastRTT      time.Duration
+}
+
+func (ps *poolStats) add(p *sofabolt.Pool) {
+	health := p.GetHealth()
+
+	p.Iterate(func(client *sofabolt.Client) {
+		ps.references += client.GetMetrics().GetReferences()
+		ps.pending += client.GetMetrics().GetPendingCommands()
+	})
+
+	ps.clients += p.Size()
+	ps.heartbeats += health.GetHeartbeats()
+	ps.failures += health.GetFailures()
+	ps.replacements += health.GetReplacements()
+	if rtt := health.GetLastRTT(); rtt > ps.lastRTT {
+		ps.lastRTT = rtt
+	}
+}
+
+func (ps *poolStats) collect(c *Collector, counter func(*prom.Desc, int64, ...string),
+	gauge func(*prom.Desc, float64, ...string), labels ...string) {
+	gauge(c.pool.clients, float64(ps.clients), labels...)
+	gauge(c.pool.references, float64(ps.references), labels...)
+	gauge(c.pool.pending, float64(ps.pending), labels...)
+	counter(c.pool.heartbeats, ps.heartbeats, labels...)
+	counter(c.pool.failures, ps.failures, labels...)
+	counter(c.pool.replacements, ps.replacements, labels...)
+	gauge(c.pool.lastRTT, ps.lastRTT.Seconds(), labels...)
+}
+
+// labelSet bounds the values of a label: the first max values seen keep their
+// names and the others are LabelOther.
+type labelSet struct {
+	sync.RWMutex
+	values map[string]struct{}
+	max    int
+}
+
+func newLabelSet(max int) *labelSet {
+	return &labelSet{
+		values: make(map[string]struct{}),
+		max:    max,
+	}
+}
+
+func (s *labelSet) get(v string) string {
+	s.RLock()
+	_, ok := s.values[v]
+	full := len(s.values) >= s.max
+	s.RUnlock()
+
+	if ok {
+		return v
+	}
+
+	if full {
+		return LabelOther
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok = s.values[v]; !ok {
+		if len(s.values) >= s.max {
+			return LabelOther
+		}
+		s.values[v] = struct{}{}
+	}
+
+	return v
+}
+
+// histogramOf exports h as a histogram of bounds, the values of h are divided by
+// scale, e.g. nanoseconds to seconds. A bucket of h is counted in the first bound
+// not less than its upper bound, which is accurate to 1/16 of the value.
+func histogramOf(d *prom.Desc, h *histogram.Histogram, bounds []float64, scale float64,
+	labels ...string) prom.Metric {
+	s := h.Snapshot()
+	counts := make([]uint64, len(bounds))
+	s.Range(func(upper int64, count int64) {
+		if i := sort.SearchFloat64s(bounds, float64(upper)/scale); i < len(bounds) {
+			counts[i] += uint64(count)
+		}
+	})
+
+	buckets := make(map[float64]uint64, len(bounds))
+	var cumulative uint64
+	for i, bound := range bounds {
+		cumulative += counts[i]
+		buckets[bound] = cumulative
+	}
+
+	return prom.MustNewConstHistogram(d, uint64(s.Count), float64(s.Sum)/scale, buckets, labels...)
+}
+
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, d := range []*prom.Desc{
+		c.server.bytesRead, c.server.bytesWrite, c.server.commands,
+		c.server.pendingCommands, c.server.connections, c.server.pendingConnections,
+		c.server.latency, c.server.requestSize, c.server.responseSize,
+		c.client.bytesRead, c.client.bytesWrite, c.client.commands, c.client.pendingCommands,
+		c.client.pendingBytes, c.client.references, c.client.used, c.client.blockedCallbacks,
+		c.client.latency, c.client.requestSize, c.client.responseSize,
+		c.pool.clients, c.pool.references, c.pool.pending, c.pool.heartbeats,
+		c.pool.failures, c.pool.replacements, c.pool.lastRTT,
+		c.writer.commands, c.writer.pendingCommands, c.writer.bytes,
+	} {
+		ch <- d
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	counter := func(d *prom.Desc, v int64, labels ...string) {
+		ch <- prom.MustNewConstMetric(d, prom.CounterValue, float64(v), labels...)
+	}
+	gauge := func(d *prom.Desc, v float64, labels ...string) {
+		ch <- prom.MustNewConstMetric(d, prom.GaugeValue, v, labels...)
+	}
+	latency := func(d *prom.Desc, h *histogram.Histogram, labels ...string) {
+		ch <- histogramOf(d, h, c.options.latencyBuckets, float64(time.Second), labels...)
+	}
+	size := func(d *prom.Desc, h *histogram.Histogram, labels ...string) {
+		ch <- histogramOf(d, h, c.options.sizeBuckets, 1, labels...)
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	for name, sm := range c.servers {
+		counter(c.server.bytesRead, sm.GetBytesRead(), name)
+		counter(c.server.bytesWrite, sm.GetBytesWrite(), name)
+		counter(c.server.commands, sm.GetCommands(), name)
+		gauge(c.server.pendingCommands, float64(sm.GetPendingCommands()), name)
+		counter(c.server.connections, sm.GetConnections(), name)
+		gauge(c.server.pendingConnections, float64(sm.GetPendingConnections()), name)
+		sm.RangeLatencyByStatus(func(status sofabolt.Status, h *histogram.Histogram) bool {
+			latency(c.server.latency, h, name, status.String())
+			return true
+		})
+		size(c.server.requestSize, sm.GetRequestSize(), name)
+		size(c.server.responseSize, sm.GetResponseSize(), name)
+	}
+
+	for name, cm := range c.clients {
+		counter(c.client.bytesRead, cm.GetBytesRead(), name)
+		counter(c.client.bytesWrite, cm.GetBytesWrite(), name)
+		counter(c.client.commands, cm.GetCommands(), name)
+		gauge(c.client.pendingCommands, float64(cm.GetPendingCommands()), name)
+		gauge(c.client.pendingBytes, float64(cm.GetPendingBytes()), name)
+		gauge(c.client.references, float64(cm.GetReferences()), name)
+		counter(c.client.used, cm.GetUsed(), name)
+		counter(c.client.blockedCallbacks, cm.GetBlockedCallbacks(), name)
+		cm.RangeLatencyByStatus(func(status sofabolt.Status, h *histogram.Histogram) bool {
+			latency(c.client.latency, h, name, status.String())
+			return true
+		})
+		size(c.client.requestSize, cm.GetRequestSize(), name)
+		size(c.client.responseSize, cm.GetResponseSize(), name)
+	}
+
+	for name, ka := range c.keepalivers {
+		// the pools of the addresses exceeding the limit are summed up
+		others := make(map[string]*poolStats, 2)
+
+		ka.Range(func(tls bool, addr string, p *sofabolt.Pool) bool {
+			scheme := "raw"
+			if tls {
+				scheme = "tls"
+			}
+
+			if c.addresses.get(addr) == LabelOther {
+				o, ok := others[scheme]
+				if !ok {
+					o = &poolStats{}
+					others[scheme] = o
+				}
+				o.add(p)
+				return true
+			}
+
+			var ps poolStats
+			ps.add(p)
+			ps.collect(c, counter, gauge, name, scheme, addr)
+			return true
+		})
+
+		for scheme, o := range others {
+			o.collect(c, counter, gauge, name, scheme, LabelOther)
+		}
+	}
+
+	for name, m := range c.writers {
+		counter(c.writer.commands, m.GetCommands(), name)
+		gauge(c.writer.pendingCommands, float64(m.GetPendingCommands()), name)
+		counter(c.writer.bytes, m.GetBytes(), name)
+	}
+}
@@ -0,0 +1,107 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package prometheus
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt"
+	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/asyncwriteconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector(WithNamespace("bolt"))
+
+	sm := &sofabolt.ServerMetrics{}
+	srv, err := sofabolt.NewServer(
+		sofabolt.WithServerMetrics(sm),
+		sofabolt.WithServerHandler(sofabolt.HandlerFunc(
+			func(rw sofabolt.ResponseWriter, req *sofabolt.Request) {
+				rw.GetResponse().SetContentString("pong")
+				rw.Write()
+			})),
+	)
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go srv.ServeConn(p1)
+
+	client, err := sofabolt.NewClient(sofabolt.WithClientConn(p0))
+	require.Nil(t, err)
+
+	collector.AddServer("rpc", sm)
+	collector.AddClient("rpc", client.GetMetrics())
+	collector.AddAsyncWriter("rpc", asyncwriteconn.NewMetrics())
+
+	req := sofabolt.AcquireRequest()
+	res := sofabolt.AcquireResponse()
+	require.Nil(t, client.DoTimeout(req, res, time.Second))
+
+	rw := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(rw, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(rw.Body)
+	require.Nil(t, err)
+
+	for _, s := range []string{
+		`bolt_server_commands_total{server="rpc"} 1`,
+		`bolt_client_used_total{client="rpc"} 1`,
+		`bolt_writer_commands_total{writer="rpc"} 0`,
+		`bolt_server_request_duration_seconds_count{server="rpc",status="success"} 1`,
+		`bolt_server_request_size_bytes_count{server="rpc"} 1`,
+		`bolt_client_request_duration_seconds_count{client="rpc",status="success"} 1`,
+		`bolt_client_response_size_bytes_count{client="rpc"} 1`,
+	} {
+		require.Contains(t, string(body), s)
+	}
+}
+
+func TestCollectorHistogram(t *testing.T) {
+	collector := NewCollector(WithNamespace("bolt"), WithLatencyBuckets([]float64{.001, .01, .1}))
+
+	sm := &sofabolt.ServerMetrics{}
+	sm.ObserveLatency(sofabolt.StatusSuccess, 500*time.Microsecond)
+	sm.ObserveLatency(sofabolt.StatusSuccess, 5*time.Millisecond)
+	sm.ObserveLatency(sofabolt.StatusSuccess, time.Second)
+	sm.ObserveLatency(sofabolt.StatusServerThreadPoolBusy, time.Millisecond/2)
+	collector.AddServer("rpc", sm)
+
+	rw := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(rw, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(rw.Body)
+	require.Nil(t, err)
+
+	for _, s := range []string{
+		`bolt_server_request_duration_seconds_bucket{server="rpc",status="success",le="0.001"} 1`,
+		`bolt_server_request_duration_seconds_bucket{server="rpc",status="success",le="0.01"} 2`,
+		`bolt_server_request_duration_seconds_bucket{server="rpc",status="success",le="0.1"} 2`,
+		`bolt_server_request_duration_seconds_bucket{server="rpc",status="success",le="+Inf"} 3`,
+		`bolt_server_request_duration_seconds_sum{server="rpc",status="success"} 1.0055`,
+		`bolt_server_request_duration_seconds_count{server="rpc",status="server-threadpool-busy"} 1`,
+	} {
+		require.Contains(t, string(body), s)
+	}
+}
+
+func TestCollectorCardinality(t *testing.T) {
+	s := newLabelSet(2)
+	require.Equal(t, "a", s.get("a"))
+	require.Equal(t, "b", s.get("b"))
+	require.Equal(t, LabelOther, s.get("c"))
+	require.Equal(t, "a", s.get("a"))
+}
@@ -0,0 +1,52 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package prometheus
+
+// OptionSetter configures a Collector.
+type OptionSetter interface {
+	Set(*Collector)
+}
+
+type OptionSetterFunc func(*Collector)
+
+func (f OptionSetterFunc) Set(c *Collector) {
+	f(c)
+}
+
+func WithNamespace(namespace string) OptionSetterFunc {
+	return OptionSetterFunc(func(c *Collector) {
+		c.options.namespace = namespace
+	})
+}
+
+func WithLatencyBuckets(buckets []float64) OptionSetterFunc {
+	return OptionSetterFunc(func(c *Collector) {
+		c.options.latencyBuckets = buckets
+	})
+}
+
+func WithSizeBuckets(buckets []float64) OptionSetterFunc {
+	return OptionSetterFunc(func(c *Collector) {
+		c.options.sizeBuckets = buckets
+	})
+}
+
+// WithMaxAddresses limits the address label of the pools to the first n addresses
+// seen, DefaultMaxAddresses by default. The pools of the others are summed up as
+// LabelOther.
+func WithMaxAddresses(n int) OptionSetterFunc {
+	return OptionSetterFunc(func(c *Collector) {
+		c.options.maxAddresses = n
+	})
+}
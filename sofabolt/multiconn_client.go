@@ -0,0 +1,147 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// MultiConnClient stripes the calls to the same address over multiple
+// connections, so a large payload does not block the small calls behind it
+// on a single TCP stream.
+//
+// Every underlying client dials and redials its own connection, thus the
+// options must contain WithClientRedial rather than WithClientConn.
+type MultiConnClient struct {
+	clients []*Client
+	next    uint32
+}
+
+func NewMultiConnClient(conns int, options ...ClientOptionSetter) (*MultiConnClient, error) {
+	if conns <= 0 {
+		return nil, errors.New("sofabolt: multiconn client needs at least one connection")
+	}
+
+	mc := &MultiConnClient{
+		clients: make([]*Client, 0, conns),
+	}
+
+	for i := 0; i < conns; i++ {
+		// every client owns its metrics to pick by pending bytes
+		opts := make([]ClientOptionSetter, 0, len(options)+1)
+		opts = append(opts, options...)
+		opts = append(opts, WithClientMetrics(&ClientMetrics{
+			created: time.Now().Unix(),
+		}))
+
+		c, err := NewClient(opts...)
+		if err != nil {
+			// nolint
+			mc.Close()
+			return nil, err
+		}
+		mc.clients = append(mc.clients, c)
+	}
+
+	return mc, nil
+}
+
+// GetClients returns the underlying clients.
+func (mc *MultiConnClient) GetClients() []*Client { return mc.clients }
+
+// GetMetrics returns the sum of metrics of the underlying clients.
+func (mc *MultiConnClient) GetMetrics() *ClientMetrics {
+	cm := &ClientMetrics{}
+	for _, c := range mc.clients {
+		cm.merge(c.GetMetrics())
+	}
+	return cm
+}
+
+func (mc *MultiConnClient) Closed() bool {
+	for _, c := range mc.clients {
+		if !c.Closed() {
+			return false
+		}
+	}
+	return true
+}
+
+func (mc *MultiConnClient) Close() error {
+	var err error
+	for _, c := range mc.clients {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Pick returns the open client with the least pending bytes.
+func (mc *MultiConnClient) Pick() (*Client, bool) {
+	var (
+		n      = len(mc.clients)
+		start  = int(atomic.AddUint32(&mc.next, 1))
+		picked *Client
+		least  int64
+	)
+
+	for i := 0; i < n; i++ {
+		c := mc.clients[(start+i)%n]
+		if c.Closed() {
+			continue
+		}
+
+		pending := c.GetMetrics().GetPendingBytes()
+		if picked == nil || pending < least {
+			picked = c
+			least = pending
+		}
+
+		if least == 0 {
+			break
+		}
+	}
+
+	return picked, picked != nil
+}
+
+// Get implements ClientGetter.
+func (mc *MultiConnClient) Get() (*Client, bool) { return mc.Pick() }
+
+func (mc *MultiConnClient) Do(req *Request, res *Response) error {
+	return mc.DoTimeout(req, res, zeroDuration)
+}
+
+func (mc *MultiConnClient) DoTimeout(req *Request, res *Response, timeout time.Duration) error {
+	c, ok := mc.Pick()
+	if !ok {
+		return ErrClientWasClosed
+	}
+	return c.DoTimeout(req, res, timeout)
+}
+
+func (mc *MultiConnClient) DoCallback(req *Request, cb ClientCallbacker) error {
+	return mc.DoCallbackTimeout(req, cb, zeroDuration)
+}
+
+func (mc *MultiConnClient) DoCallbackTimeout(req *Request, cb ClientCallbacker, timeout time.Duration) error {
+	c, ok := mc.Pick()
+	if !ok {
+		return ErrClientWasClosed
+	}
+	return c.DoCallbackTimeout(req, cb, timeout)
+}
@@ -0,0 +1,81 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiConnClientStriping(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+
+	srv, err := NewServer(
+		WithServerAsync(true),
+		WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			if len(req.GetContent()) > 1024 {
+				time.Sleep(500 * time.Millisecond)
+			}
+			rw.GetResponse().SetContent(req.GetContent()[:1])
+			rw.Write()
+		})),
+	)
+	require.Nil(t, err)
+	go srv.Serve(ln)
+
+	mc, err := NewMultiConnClient(2, WithClientRedial(DialerFunc(func() (net.Conn, error) {
+		return net.Dial("tcp4", ln.Addr().String())
+	})))
+	require.Nil(t, err)
+	defer mc.Close()
+
+	big := AcquireRequest()
+	big.SetContentString(strings.Repeat("b", 4096))
+	doneCh := make(chan error, 1)
+	require.Nil(t, mc.DoCallbackTimeout(big, ClientCallbackerFunc(func(err error, ictx *InvokeContext) {
+		doneCh <- err
+	}), 2*time.Second))
+
+	require.Greater(t, mc.GetMetrics().GetPendingBytes(), int64(4096))
+
+	small := AcquireRequest()
+	small.SetContentString("s")
+	res := AcquireResponse()
+	started := time.Now()
+	require.Nil(t, mc.DoTimeout(small, res, 2*time.Second))
+	require.Less(t, int64(time.Since(started)), int64(400*time.Millisecond))
+	require.Equal(t, "s", string(res.GetContent()))
+
+	require.Nil(t, <-doneCh)
+	require.Equal(t, int64(0), mc.GetMetrics().GetPendingBytes())
+	require.Equal(t, int64(2), mc.GetMetrics().GetUsed())
+}
+
+func TestMultiConnClientClosed(t *testing.T) {
+	p0, _ := net.Pipe()
+	mc, err := NewMultiConnClient(1, WithClientConn(p0))
+	require.Nil(t, err)
+	require.Nil(t, mc.Close())
+	require.True(t, mc.Closed())
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	require.Equal(t, ErrClientWasClosed, mc.Do(req, res))
+}
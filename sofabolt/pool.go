@@ -24,11 +24,22 @@ type Pool struct {
 	sync.RWMutex
 	clients []*Client
 	next    int
+	picker  Picker
+	health  PoolHealth
 }
 
 func NewPool() *Pool {
+	return NewPoolWithPicker(RoundRobinPicker)
+}
+
+func NewPoolWithPicker(picker Picker) *Pool {
+	if picker == nil {
+		picker = RoundRobinPicker
+	}
+
 	return &Pool{
 		clients: make([]*Client, 0, 8),
+		picker:  picker,
 	}
 }
 
@@ -40,6 +51,10 @@ func (p *Pool) Size() int {
 	return n
 }
 
+// GetHealth returns the heartbeat results of the pool, they are gone with the
+// pool once it's drained or evicted.
+func (p *Pool) GetHealth() *PoolHealth { return &p.health }
+
 func (p *Pool) Iterate(fn func(client *Client)) {
 	p.Lock()
 	for i := range p.clients {
@@ -94,6 +109,19 @@ func (p *Pool) Push(client *Client) {
 	p.Unlock()
 }
 
+// pushLimited pushes the client unless the pool has max clients already, max is
+// unlimited if it's not positive.
+func (p *Pool) pushLimited(client *Client, max int) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	if max > 0 && len(p.clients) >= max {
+		return false
+	}
+	p.clients = append(p.clients, client)
+	return true
+}
+
 func (p *Pool) Get() (*Client, bool) {
 	var (
 		client *Client
@@ -106,7 +134,10 @@ func (p *Pool) Get() (*Client, bool) {
 		p.Unlock()
 		return nil, false
 	}
-	p.next = (p.next + 1) % n
+	if p.picker == nil {
+		p.picker = RoundRobinPicker
+	}
+	p.next = p.picker.Pick(p.clients, p.next)
 	client = p.clients[p.next]
 	p.Unlock()
 
@@ -125,10 +156,12 @@ func (p *Pool) MarshalJSON() ([]byte, error) {
 
 	type status struct {
 		Next    int            `json:"next"`
+		Health  *PoolHealth    `json:"health"`
 		Clients []clientStatus `json:"clients"`
 	}
 
 	s := status{
+		Health:  &p.health,
 		Clients: make([]clientStatus, 0, 8),
 	}
 
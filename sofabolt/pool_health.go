@@ -0,0 +1,76 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	uatomic "go.uber.org/atomic"
+)
+
+// PoolHealth records the heartbeat results of an address.
+type PoolHealth struct {
+	lastRTT       int64
+	lastHeartbeat int64
+	heartbeats    int64
+	failures      int64
+	replacements  int64
+	lastError     uatomic.String
+}
+
+func (ph *PoolHealth) GetLastRTT() time.Duration {
+	return time.Duration(atomic.LoadInt64(&ph.lastRTT))
+}
+func (ph *PoolHealth) GetLastHeartbeat() int64 { return atomic.LoadInt64(&ph.lastHeartbeat) }
+func (ph *PoolHealth) GetHeartbeats() int64    { return atomic.LoadInt64(&ph.heartbeats) }
+func (ph *PoolHealth) GetFailures() int64      { return atomic.LoadInt64(&ph.failures) }
+func (ph *PoolHealth) GetReplacements() int64  { return atomic.LoadInt64(&ph.replacements) }
+func (ph *PoolHealth) GetLastError() string    { return ph.lastError.Load() }
+
+func (ph *PoolHealth) onHeartbeat(t time.Time, rtt time.Duration, err error) {
+	atomic.StoreInt64(&ph.lastHeartbeat, t.Unix())
+	atomic.AddInt64(&ph.heartbeats, 1)
+	if err != nil {
+		atomic.AddInt64(&ph.failures, 1)
+		ph.lastError.Store(err.Error())
+		return
+	}
+	atomic.StoreInt64(&ph.lastRTT, int64(rtt))
+}
+
+func (ph *PoolHealth) addReplacements(n int64) {
+	atomic.AddInt64(&ph.replacements, n)
+}
+
+func (ph *PoolHealth) MarshalJSON() ([]byte, error) {
+	type status struct {
+		LastRTT       string `json:"last_rtt"`
+		LastHeartbeat int64  `json:"last_heartbeat"`
+		Heartbeats    int64  `json:"heartbeats"`
+		Failures      int64  `json:"failures"`
+		Replacements  int64  `json:"replacements"`
+		LastError     string `json:"last_error"`
+	}
+
+	return json.Marshal(status{
+		LastRTT:       ph.GetLastRTT().String(),
+		LastHeartbeat: ph.GetLastHeartbeat(),
+		Heartbeats:    ph.GetHeartbeats(),
+		Failures:      ph.GetFailures(),
+		Replacements:  ph.GetReplacements(),
+		LastError:     ph.GetLastError(),
+	})
+}
@@ -0,0 +1,105 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import "math/rand"
+
+// Picker picks the index of the client to use from a non-empty clients.
+// next is the index picked last time.
+type Picker interface {
+	Pick(clients []*Client, next int) int
+}
+
+type PickerFunc func(clients []*Client, next int) int
+
+func (f PickerFunc) Pick(clients []*Client, next int) int {
+	return f(clients, next)
+}
+
+var (
+	// RoundRobinPicker picks the clients in turn.
+	RoundRobinPicker = PickerFunc(pickRoundRobin)
+	// LeastReferencesPicker picks the client with the fewest in-flight calls.
+	LeastReferencesPicker = PickerFunc(pickLeastReferences)
+	// LeastPendingPicker picks the client with the fewest unflushed commands.
+	LeastPendingPicker = PickerFunc(pickLeastPending)
+	// LeastRecentlyUsedPicker picks the client which was used least recently.
+	LeastRecentlyUsedPicker = PickerFunc(pickLeastRecentlyUsed)
+	// RandomTwoChoicesPicker picks the one with fewer references of two random
+	// clients.
+	RandomTwoChoicesPicker = PickerFunc(pickRandomTwoChoices)
+)
+
+func pickRoundRobin(clients []*Client, next int) int {
+	return (next + 1) % len(clients)
+}
+
+func pickLeast(clients []*Client, next int, load func(c *Client) int64) int {
+	// start after the last one to break the ties in turn
+	n := len(clients)
+	picked := (next + 1) % n
+	least := load(clients[picked])
+	for i := 1; i < n && least > 0; i++ {
+		j := (picked + i) % n
+		if l := load(clients[j]); l < least {
+			least = l
+			picked = j
+		}
+	}
+	return picked
+}
+
+func pickLeastReferences(clients []*Client, next int) int {
+	return pickLeast(clients, next, func(c *Client) int64 {
+		return c.GetMetrics().GetReferences()
+	})
+}
+
+func pickLeastPending(clients []*Client, next int) int {
+	return pickLeast(clients, next, func(c *Client) int64 {
+		return c.GetMetrics().GetPendingCommands()
+	})
+}
+
+func pickLeastRecentlyUsed(clients []*Client, next int) int {
+	n := len(clients)
+	picked := (next + 1) % n
+	lastUsed := clients[picked].GetMetrics().GetLastUsed()
+	for i := 1; i < n; i++ {
+		j := (picked + i) % n
+		if l := clients[j].GetMetrics().GetLastUsed(); l < lastUsed {
+			lastUsed = l
+			picked = j
+		}
+	}
+	return picked
+}
+
+func pickRandomTwoChoices(clients []*Client, next int) int {
+	n := len(clients)
+	if n == 1 {
+		return 0
+	}
+
+	i := rand.Intn(n)
+	j := rand.Intn(n - 1)
+	if j >= i {
+		j++
+	}
+
+	if clients[j].GetMetrics().GetReferences() < clients[i].GetMetrics().GetReferences() {
+		return j
+	}
+	return i
+}
@@ -1,7 +1,9 @@
 package sofabolt
 
 import (
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,3 +22,47 @@ func TestRangeDelete(t *testing.T) {
 	})
 	assert.Equal(0, pool.Size())
 }
+
+func newPickerTestClients(n int) []*Client {
+	clients := make([]*Client, n)
+	for i := range clients {
+		clients[i] = &Client{metrics: &ClientMetrics{}}
+	}
+	return clients
+}
+
+func TestPoolPicker(t *testing.T) {
+	assert := assert.New(t)
+
+	clients := newPickerTestClients(3)
+	pool := NewPoolWithPicker(LeastReferencesPicker)
+	for _, c := range clients {
+		pool.Push(c)
+	}
+
+	clients[0].GetMetrics().AddReferences(2)
+	clients[1].GetMetrics().AddReferences(1)
+	clients[2].GetMetrics().AddReferences(3)
+	c, ok := pool.Get()
+	assert.True(ok)
+	assert.Equal(clients[1], c)
+
+	atomic.StoreInt64(&clients[0].GetMetrics().pendingCommands, 3)
+	atomic.StoreInt64(&clients[1].GetMetrics().pendingCommands, 2)
+	atomic.StoreInt64(&clients[2].GetMetrics().pendingCommands, 1)
+	assert.Equal(2, LeastPendingPicker.Pick(clients, 0))
+
+	// used within the same second
+	base := time.Now().Truncate(time.Second).UnixNano()
+	atomic.StoreInt64(&clients[0].GetMetrics().lastUsed, base+30)
+	atomic.StoreInt64(&clients[1].GetMetrics().lastUsed, base+10)
+	atomic.StoreInt64(&clients[2].GetMetrics().lastUsed, base+20)
+	assert.Equal(1, LeastRecentlyUsedPicker.Pick(clients, 0))
+
+	assert.Equal(0, RoundRobinPicker.Pick(clients, 2))
+
+	for i := 0; i < 16; i++ {
+		// never picks the most referenced one
+		assert.NotEqual(2, RandomTwoChoicesPicker.Pick(clients, 0))
+	}
+}
@@ -0,0 +1,237 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrProtoMismatchCodec = errors.New("sofabolt: codec is not protobuf")
+
+// SetProtoMessage marshals m to the content and sets the codec to CodecProtobuf
+// and the class to the full name of m. The SOFARPC calls use the class of the
+// SofaRequest and SofaResponse instead, as InvokeProto does.
+func (c *Request) SetProtoMessage(m proto.Message) error {
+	var err error
+	c.command.content, err = proto.MarshalOptions{}.MarshalAppend(c.command.content[:0], m)
+	if err != nil {
+		return err
+	}
+	c.SetCodec(CodecProtobuf).SetClassString(string(m.ProtoReflect().Descriptor().FullName()))
+	return nil
+}
+
+// GetProtoMessage unmarshals the content to m.
+func (c *Request) GetProtoMessage(m proto.Message) error {
+	if c.GetCodec() != CodecProtobuf {
+		return ErrProtoMismatchCodec
+	}
+	return proto.Unmarshal(c.GetContent(), m)
+}
+
+// SetProtoMessage marshals m to the content and sets the codec to CodecProtobuf
+// and the class to the full name of m. The SOFARPC calls use the class of the
+// SofaRequest and SofaResponse instead, as InvokeProto does.
+func (c *Response) SetProtoMessage(m proto.Message) error {
+	var err error
+	c.command.content, err = proto.MarshalOptions{}.MarshalAppend(c.command.content[:0], m)
+	if err != nil {
+		return err
+	}
+	c.SetCodec(CodecProtobuf).SetClassString(string(m.ProtoReflect().Descriptor().FullName()))
+	return nil
+}
+
+// GetProtoMessage unmarshals the content to m.
+func (c *Response) GetProtoMessage(m proto.Message) error {
+	if c.GetCodec() != CodecProtobuf {
+		return ErrProtoMismatchCodec
+	}
+	return proto.Unmarshal(c.GetContent(), m)
+}
+
+// InvokeProto calls method of the SOFARPC service with the protobuf args and
+// unmarshals the app response to reply. The timeout is taken from the deadline of ctx,
+// and ctx.Err() is returned once ctx is done.
+// The error responded by the remote is a *RemoteError.
+func (c *Client) InvokeProto(ctx context.Context, service, method string, args, reply proto.Message) error {
+	timeout, err := contextTimeout(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+
+	req.SetContext(ctx)
+	req.SetTimeout(uint32(timeout / time.Millisecond))
+	if err = req.SetProtoMessage(args); err != nil {
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+		return err
+	}
+	req.SetClassString(ClassRequest)
+	req.setSofaHeaders("", service, method)
+	req.GetRPCHeaders().SetProtocol("bolt")
+
+	err = c.doContext(ctx, req, res, timeout)
+	if isAbandoned(err) { // the request may be still referenced, let gc handle it.
+		return err
+	}
+
+	defer func() {
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	if res.GetStatus() != StatusSuccess {
+		return newStatusRemoteError(res)
+	}
+
+	if re := newHeaderRemoteError(res); re != nil {
+		return re
+	}
+
+	return res.GetProtoMessage(reply)
+}
+
+// ProtoInvoker invokes protobuf methods, it's implemented by *Client.
+type ProtoInvoker interface {
+	InvokeProto(ctx context.Context, service, method string, args, reply proto.Message) error
+}
+
+// ProtoRegistrar registers protobuf methods, it's implemented by *Server and *ProtoServeMux.
+type ProtoRegistrar interface {
+	HandleProto(service, method string, req proto.Message, fn ProtoMethodFunc)
+}
+
+// ProtoMethodFunc serves a protobuf method. req is a new message of the type
+// registered by HandleProto.
+type ProtoMethodFunc func(ctx context.Context, req proto.Message) (proto.Message, error)
+
+type protoMethod struct {
+	service string
+	method  string
+	req     proto.Message
+	fn      ProtoMethodFunc
+}
+
+// ProtoServeMux is a Handler which dispatches the protobuf SOFARPC requests by the
+// service and method headers. Requests of other codecs or classes, or of unknown
+// methods are passed to the fallback handler, or responded with StatusNoProcessor
+// if it's nil.
+type ProtoServeMux struct {
+	sync.RWMutex
+	methods  map[string]*protoMethod
+	fallback Handler
+}
+
+func NewProtoServeMux(fallback Handler) *ProtoServeMux {
+	return &ProtoServeMux{
+		methods:  make(map[string]*protoMethod, 8),
+		fallback: fallback,
+	}
+}
+
+// HandleProto registers fn to serve method of service. The content of requests is
+// unmarshaled into a new message of the type of req.
+func (m *ProtoServeMux) HandleProto(service, method string, req proto.Message, fn ProtoMethodFunc) {
+	m.Lock()
+	if m.methods == nil {
+		m.methods = make(map[string]*protoMethod, 8)
+	}
+	m.methods[protoMethodKey(service, method)] = &protoMethod{service: service, method: method, req: req, fn: fn}
+	m.Unlock()
+}
+
+func (m *ProtoServeMux) ServeSofaBOLT(rw ResponseWriter, req *Request) {
+	pm := m.lookup(req)
+	if pm != nil {
+		pm.serve(rw, req)
+		return
+	}
+
+	if m.fallback != nil {
+		m.fallback.ServeSofaBOLT(rw, req)
+		return
+	}
+
+	rw.GetResponse().SetStatus(StatusNoProcessor)
+	// nolint
+	rw.Write()
+}
+
+func (m *ProtoServeMux) lookup(req *Request) *protoMethod {
+	if req.GetCodec() != CodecProtobuf || string(req.GetClass()) != ClassRequest {
+		return nil
+	}
+
+	headers := req.GetRPCHeaders()
+
+	m.RLock()
+	pm := m.methods[protoMethodKey(headers.GetTargetService(), headers.GetMethodName())]
+	m.RUnlock()
+
+	return pm
+}
+
+func (pm *protoMethod) serve(rw ResponseWriter, req *Request) {
+	res := rw.GetResponse()
+
+	args := pm.req.ProtoReflect().New().Interface()
+	if err := req.GetProtoMessage(args); err != nil {
+		res.SetStatus(StatusServerDeseralException)
+		// nolint
+		rw.Write()
+		return
+	}
+
+	reply, err := pm.call(req.GetContext(), args)
+	if err != nil {
+		res.SetCodec(CodecProtobuf).SetClassString(ClassResponse).SetContentString(err.Error())
+		res.GetHeaders().Set(headerResponseError, "true")
+
+	} else if reply != nil {
+		if err = res.SetProtoMessage(reply); err != nil {
+			res.SetStatus(StatusServerSerialException)
+		}
+		res.SetClassString(ClassResponse)
+	}
+
+	// nolint
+	rw.Write()
+}
+
+func (pm *protoMethod) call(ctx context.Context, args proto.Message) (reply proto.Message, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sofabolt: %s.%s panic: %v", pm.service, pm.method, r)
+		}
+	}()
+	return pm.fn(ctx, args)
+}
+
+func protoMethodKey(service, method string) string {
+	return service + "#" + method
+}
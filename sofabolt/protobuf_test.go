@@ -0,0 +1,142 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoMessage(t *testing.T) {
+	var req Request
+	require.Nil(t, req.SetProtoMessage(&wrapperspb.StringValue{Value: "hello"}))
+	require.Equal(t, CodecProtobuf, req.GetCodec())
+	require.Equal(t, "google.protobuf.StringValue", string(req.GetClass()))
+
+	var s wrapperspb.StringValue
+	require.Nil(t, req.GetProtoMessage(&s))
+	require.Equal(t, "hello", s.GetValue())
+
+	var res Response
+	require.Nil(t, res.SetProtoMessage(&wrapperspb.Int64Value{Value: 42}))
+	require.Equal(t, "google.protobuf.Int64Value", string(res.GetClass()))
+
+	var i wrapperspb.Int64Value
+	require.Nil(t, res.GetProtoMessage(&i))
+	require.Equal(t, int64(42), i.GetValue())
+
+	res.SetCodec(CodecHessian2)
+	require.Equal(t, ErrProtoMismatchCodec, res.GetProtoMessage(&i))
+}
+
+func TestClientInvokeProto(t *testing.T) {
+	mux := NewProtoServeMux(nil)
+	mux.HandleProto("com.alipay.test.EchoService:1.0", "echo", &wrapperspb.StringValue{},
+		func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return &wrapperspb.StringValue{Value: "echo " + req.(*wrapperspb.StringValue).GetValue()}, nil
+		})
+	mux.HandleProto("com.alipay.test.EchoService:1.0", "fail", &wrapperspb.StringValue{},
+		func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return nil, errors.New("failed")
+		})
+	mux.HandleProto("com.alipay.test.EchoService:1.0", "panic", &wrapperspb.StringValue{},
+		func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			panic("oops")
+		})
+
+	p0, p1 := net.Pipe()
+	srv, err := NewServer(WithServerHandler(mux))
+	require.Nil(t, err)
+
+	go func() {
+		srv.ServeConn(p1)
+	}()
+
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var reply wrapperspb.StringValue
+	require.Nil(t, c.InvokeProto(ctx, "com.alipay.test.EchoService:1.0", "echo", &wrapperspb.StringValue{Value: "hello"}, &reply))
+	require.Equal(t, "echo hello", reply.GetValue())
+
+	err = c.InvokeProto(ctx, "com.alipay.test.EchoService:1.0", "fail", &wrapperspb.StringValue{Value: "hello"}, &reply)
+	require.Equal(t, &RemoteError{Status: StatusServerException, Message: "failed"}, err)
+
+	err = c.InvokeProto(ctx, "com.alipay.test.EchoService:1.0", "panic", &wrapperspb.StringValue{Value: "hello"}, &reply)
+	require.Equal(t, &RemoteError{
+		Status:  StatusServerException,
+		Message: "sofabolt: com.alipay.test.EchoService:1.0.panic panic: oops",
+	}, err)
+
+	err = c.InvokeProto(ctx, "com.alipay.test.EchoService:1.0", "unknown", &wrapperspb.StringValue{Value: "hello"}, &reply)
+	require.True(t, errors.Is(err, ErrRemoteNoProcessor))
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	req.SetCodec(CodecProtobuf).SetClassString(ClassRequest).SetContent([]byte{0xff})
+	req.setSofaHeaders("", "com.alipay.test.EchoService:1.0", "echo")
+	require.Nil(t, c.DoTimeout(req, res, time.Second))
+	require.True(t, errors.Is(NewRemoteError(res), ErrRemoteServerDeserialException))
+}
+
+func TestServerHandleProto(t *testing.T) {
+	p0, p1 := net.Pipe()
+	srv, err := NewServer(WithServerHandler(HandlerFunc(func(rw ResponseWriter, r *Request) {
+		rw.GetResponse().SetStatus(StatusNoProcessor)
+		rw.Write()
+	})))
+	require.Nil(t, err)
+
+	var r ProtoRegistrar = srv
+	r.HandleProto("com.alipay.test.EchoService:1.0", "echo", new(wrapperspb.StringValue),
+		func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return req, nil
+		})
+
+	go func() {
+		srv.ServeConn(p1)
+	}()
+
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var invoker ProtoInvoker = c
+	var reply wrapperspb.StringValue
+	require.Nil(t, invoker.InvokeProto(ctx, "com.alipay.test.EchoService:1.0", "echo", &wrapperspb.StringValue{Value: "hello"}, &reply))
+	require.Equal(t, "hello", reply.GetValue())
+
+	// InvokeCodec and InvokeProto send the same protobuf SOFARPC requests
+	reply.Reset()
+	require.Nil(t, c.InvokeCodec(ctx, CodecProtobuf, "com.alipay.test.EchoService:1.0", "echo",
+		&reply, &wrapperspb.StringValue{Value: "codec"}))
+	require.Equal(t, "codec", reply.GetValue())
+
+	err = invoker.InvokeProto(ctx, "com.alipay.test.EchoService:1.0", "unknown", &wrapperspb.StringValue{Value: "hello"}, &reply)
+	require.True(t, errors.Is(err, ErrRemoteNoProcessor))
+}
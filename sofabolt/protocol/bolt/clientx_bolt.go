@@ -141,9 +141,12 @@ func (xb *ClientConnBOLT) dispatchResponse(res *sofabolt.Response) {
 		// We've not got any pending request. It usually means that
 		// Write partially failed (timeout or request oneway),
 		// and request was already removed;
-	} else {
-		ictx.Invoke(nil, res)
+		return
 	}
+
+	xb.x.GetMetrics().ObserveRoundTrip(res.GetStatus(), time.Since(ictx.GetCreated()))
+	xb.x.GetMetrics().ObserveResponseSize(res.Size())
+	ictx.Invoke(nil, res)
 }
 
 func (xb *ClientConnBOLT) DoCallback(req *sofabolt.Request, cb sofabolt.ClientCallbacker) error {
@@ -198,6 +201,7 @@ func (xb *ClientConnBOLT) invoke(ctx *sofabolt.InvokeContext, timeout time.Durat
 	rid := uint32(id)
 	ctx.GetRequest().SetRequestID(rid)
 	xb.addRequestContext(rid, ctx)
+	xb.x.GetMetrics().ObserveRequestSize(ctx.GetRequest().Size())
 
 	err := xb.x.Send(ctx.GetRequest())
 	if err != nil {
@@ -0,0 +1,242 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sofastack/sofa-hessian-go/javaobject"
+	"github.com/sofastack/sofa-hessian-go/sofahessian"
+)
+
+// Sentinel errors of the response statuses. A *RemoteError matches the one of
+// its status, e.g. errors.Is(err, ErrRemoteTimeout).
+var (
+	ErrRemoteError                   error = &statusError{status: StatusError}
+	ErrRemoteServerException         error = &statusError{status: StatusServerException}
+	ErrRemoteUnknown                 error = &statusError{status: StatusUnknown}
+	ErrRemoteServerThreadPoolBusy    error = &statusError{status: StatusServerThreadPoolBusy}
+	ErrRemoteErrorComm               error = &statusError{status: StatusErrorComm}
+	ErrRemoteNoProcessor             error = &statusError{status: StatusNoProcessor}
+	ErrRemoteTimeout                 error = &statusError{status: StatusTimeout}
+	ErrRemoteClientSendError         error = &statusError{status: StatusClientSendError}
+	ErrRemoteCodecException          error = &statusError{status: StatusCodecException}
+	ErrRemoteConnectionClosed        error = &statusError{status: StatusConnectionClosed}
+	ErrRemoteServerSerialException   error = &statusError{status: StatusServerSerialException}
+	ErrRemoteServerDeserialException error = &statusError{status: StatusServerDeseralException}
+)
+
+// nolint
+func init() {
+	sofaregistry.RegisterJavaClass(javaobject.JavaLangStackTraceElement{})
+}
+
+type statusError struct {
+	status Status
+}
+
+func (e *statusError) Error() string {
+	return "sofabolt: remote " + e.status.String()
+}
+
+// RemoteError is the error responded by the remote.
+type RemoteError struct {
+	// Status is the response status. It's StatusServerException if the provider
+	// threw an exception or marked the SofaResponse as error.
+	Status Status
+	// Class is the java exception class. It's empty if no exception was responded.
+	Class      string
+	Message    string
+	StackTrace javaobject.JavaLangStackTraceElements
+}
+
+func (e *RemoteError) Error() string {
+	var b strings.Builder
+	b.WriteString("sofabolt: remote ")
+	b.WriteString(e.Status.String())
+	if e.Class != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Class)
+	}
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	return b.String()
+}
+
+func (e *RemoteError) Is(target error) bool {
+	se, ok := target.(*statusError)
+	return ok && se.status == e.Status
+}
+
+// NewRemoteError returns the *RemoteError of res or nil if res succeeded.
+// The SofaResponse is decoded from the content if the status is success and
+// the response is not flagged as error by the header, the error of decoding it
+// is returned wrapped.
+func NewRemoteError(res *Response) error {
+	if res.GetStatus() != StatusSuccess {
+		return newStatusRemoteError(res)
+	}
+
+	if re := newHeaderRemoteError(res); re != nil {
+		return re
+	}
+
+	if string(res.GetClass()) != ClassResponse {
+		return nil
+	}
+
+	var sr SofaResponse
+	if err := res.GetSofaResponse(&sr); err != nil {
+		return fmt.Errorf("sofabolt: failed to decode the SofaResponse: %w", err)
+	}
+
+	if re := newSofaRemoteError(&sr); re != nil {
+		return re
+	}
+	return nil
+}
+
+// newStatusRemoteError builds the error of a non-success response whose content
+// may be a hessian encoded exception.
+func newStatusRemoteError(res *Response) *RemoteError {
+	re := &RemoteError{Status: res.GetStatus()}
+
+	content := res.GetContent()
+	if len(content) == 0 || res.GetCodec() != CodecHessian2 {
+		return re
+	}
+
+	dctx := sofahessian.AcquireHessianDecodeContext().
+		SetVersion(sofahessian.Hessian3xV2).
+		SetClassRegistry(&sofaregistry)
+	bbr := sofahessian.AcquireBytesBufioReader(content)
+	v, err := sofahessian.DecodeHessian3V2(dctx, bbr.GetBufioReader())
+	sofahessian.ReleaseBytesBufioReader(bbr)
+	sofahessian.ReleaseHessianDecodeContext(dctx)
+	if err == nil {
+		re.fillThrowable(v)
+	}
+
+	return re
+}
+
+// newHeaderRemoteError returns nil if res is not flagged as error by the header
+// sofa_head_response_error, which is how SOFARPC responds errors of non-hessian codecs.
+func newHeaderRemoteError(res *Response) *RemoteError {
+	if res.GetHeaders().Get(headerResponseError) != "true" {
+		return nil
+	}
+	return &RemoteError{Status: StatusServerException, Message: string(res.GetContent())}
+}
+
+// newSofaRemoteError returns nil if sr is neither an error nor an exception.
+func newSofaRemoteError(sr *SofaResponse) *RemoteError {
+	if sr.IsError {
+		return &RemoteError{Status: StatusServerException, Message: sr.ErrorMsg}
+	}
+
+	re := &RemoteError{Status: StatusServerException}
+	if !re.fillThrowable(sr.AppResponse) {
+		return nil
+	}
+	return re
+}
+
+// fillThrowable fills the class, message and stack trace if v is a java.lang.Throwable.
+func (e *RemoteError) fillThrowable(v interface{}) bool {
+	switch x := v.(type) {
+	case *javaobject.SofaRPCServerException:
+		e.Class = x.GetJavaClassName()
+		e.Message = x.DetailMessage
+		e.StackTrace = x.StackTrace
+		return true
+
+	case *sofahessian.JavaObject:
+		var message, stackTrace interface{}
+		var found int
+		for i := 0; i < x.Len(); i++ {
+			switch x.GetKey(i) {
+			case "detailMessage":
+				message = x.GetValue(i)
+				found++
+			case "stackTrace":
+				stackTrace = x.GetValue(i)
+				found++
+			}
+		}
+		if found != 2 {
+			return false
+		}
+
+		e.Class = x.GetJavaClassName()
+		e.Message, _ = message.(string)
+		e.StackTrace = toStackTrace(stackTrace)
+		return true
+	}
+
+	return false
+}
+
+func toStackTrace(v interface{}) javaobject.JavaLangStackTraceElements {
+	if st, ok := v.(javaobject.JavaLangStackTraceElements); ok {
+		return st
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	st := make(javaobject.JavaLangStackTraceElements, 0, len(list))
+	for _, i := range list {
+		switch x := i.(type) {
+		case *javaobject.JavaLangStackTraceElement:
+			st = append(st, *x)
+		case javaobject.JavaLangStackTraceElement:
+			st = append(st, x)
+		case *sofahessian.JavaObject:
+			var e javaobject.JavaLangStackTraceElement
+			for j := 0; j < x.Len(); j++ {
+				switch x.GetKey(j) {
+				case "declaringClass":
+					e.DeclaringClass, _ = x.GetValue(j).(string)
+				case "methodName":
+					e.MethodName, _ = x.GetValue(j).(string)
+				case "fileName":
+					e.FileName, _ = x.GetValue(j).(string)
+				case "lineNumber":
+					e.LineNumber = toInt32(x.GetValue(j))
+				}
+			}
+			st = append(st, e)
+		}
+	}
+
+	return st
+}
+
+func toInt32(v interface{}) int32 {
+	switch x := v.(type) {
+	case int32:
+		return x
+	case int64:
+		return int32(x)
+	case int:
+		return int32(x)
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sofastack/sofa-hessian-go/javaobject"
+	"github.com/sofastack/sofa-hessian-go/sofahessian"
+	"github.com/stretchr/testify/require"
+)
+
+type illegalArgumentException struct {
+	DetailMessage string                                `hessian:"detailMessage"`
+	StackTrace    javaobject.JavaLangStackTraceElements `hessian:"stackTrace"`
+}
+
+func (e *illegalArgumentException) GetJavaClassName() string {
+	return "java.lang.IllegalArgumentException"
+}
+
+func TestStatusString(t *testing.T) {
+	for status, s := range map[Status]string{
+		StatusSuccess:                "success",
+		StatusError:                  "error",
+		StatusServerException:        "server-exception",
+		StatusUnknown:                "unknown",
+		StatusServerThreadPoolBusy:   "server-threadpool-busy",
+		StatusErrorComm:              "error-comm",
+		StatusNoProcessor:            "no-processor",
+		StatusTimeout:                "timeout",
+		StatusClientSendError:        "client-send-error",
+		StatusCodecException:         "codec-exception",
+		StatusConnectionClosed:       "connection-closed",
+		StatusServerSerialException:  "server-serial-exception",
+		StatusServerDeseralException: "server-deserial-exception",
+		Status(100):                  "unknown status",
+	} {
+		require.Equal(t, s, status.String())
+	}
+}
+
+func TestNewRemoteError(t *testing.T) {
+	exception := &illegalArgumentException{
+		DetailMessage: "bad argument",
+		StackTrace: javaobject.JavaLangStackTraceElements{
+			{DeclaringClass: "com.alipay.test.UserServiceImpl", MethodName: "save", FileName: "UserServiceImpl.java", LineNumber: 42},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var res Response
+		require.Nil(t, res.SetSofaResponse(&SofaResponse{AppResponse: "hello"}))
+		require.Nil(t, NewRemoteError(&res))
+	})
+
+	t.Run("app exception", func(t *testing.T) {
+		var res Response
+		require.Nil(t, res.SetSofaResponse(&SofaResponse{AppResponse: exception}))
+		err := NewRemoteError(&res)
+		require.True(t, errors.Is(err, ErrRemoteServerException))
+
+		var re *RemoteError
+		require.True(t, errors.As(err, &re))
+		require.Equal(t, "java.lang.IllegalArgumentException", re.Class)
+		require.Equal(t, "bad argument", re.Message)
+		require.Equal(t, exception.StackTrace, re.StackTrace)
+		require.Equal(t, "sofabolt: remote server-exception: java.lang.IllegalArgumentException: bad argument", err.Error())
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		var res Response
+		res.SetClass([]byte(ClassResponse)).SetCodec(CodecHessian2).SetContent([]byte("corrupt"))
+		err := NewRemoteError(&res)
+		require.NotNil(t, errors.Unwrap(err))
+		var re *RemoteError
+		require.False(t, errors.As(err, &re))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		content, err := sofahessian.EncodeHessian3V2(sofahessian.NewEncodeContext(), exception)
+		require.Nil(t, err)
+
+		var res Response
+		res.SetStatus(StatusServerThreadPoolBusy).SetCodec(CodecHessian2).SetContent(content)
+		err = NewRemoteError(&res)
+		require.True(t, errors.Is(err, ErrRemoteServerThreadPoolBusy))
+		require.False(t, errors.Is(err, ErrRemoteServerException))
+		require.Equal(t, "java.lang.IllegalArgumentException", err.(*RemoteError).Class)
+		require.Equal(t, "bad argument", err.(*RemoteError).Message)
+
+		res.SetContent(nil)
+		require.Equal(t, &RemoteError{Status: StatusServerThreadPoolBusy}, NewRemoteError(&res))
+	})
+}
@@ -16,6 +16,7 @@ package sofabolt
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/sofastack/sofa-hessian-go/javaobject"
 	"github.com/sofastack/sofa-hessian-go/sofahessian"
@@ -26,6 +27,7 @@ type Request struct {
 	noCopy    noCopy
 	command   Command
 	ctx       context.Context
+	received  time.Time
 	tbconn    javaobject.TBRemotingConnectionRequest
 	tbconnbuf []byte
 }
@@ -110,6 +112,7 @@ func (c *Request) Reset() {
 	c.command.SetType(typ)
 	c.command.SetCMDCode(cmdcode)
 	c.ctx = nil
+	c.received = time.Time{}
 }
 
 func (c *Request) SetProto(p Proto) *Request       { c.command.SetProto(p); return c }
@@ -168,3 +171,7 @@ func (c *Request) GetClass() []byte       { return c.command.GetClass() }
 func (c *Request) GetHeaders() *SimpleMap { return c.command.GetHeaders() }
 func (c *Request) GetContent() []byte     { return c.command.GetContent() }
 func (c *Request) Size() int              { return c.command.Size() }
+
+func (c *Request) StringWithContentLimit(limit int) string {
+	return c.command.StringWithContentLimit(limit)
+}
@@ -3,6 +3,7 @@ package sofabolt
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -18,3 +19,12 @@ func TestRequestCopyTo(t *testing.T) {
 	r1.CopyTo(r2)
 	assert.NotEqual(r1.GetContext(), r2.GetContext())
 }
+
+func TestRequestReset(t *testing.T) {
+	assert := assert.New(t)
+	r := new(Request).SetContext(context.TODO())
+	r.received = time.Now()
+	r.Reset()
+	assert.True(r.received.IsZero())
+	assert.Equal(context.Background(), r.GetContext())
+}
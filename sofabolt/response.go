@@ -163,3 +163,7 @@ func (c *Response) GetClass() []byte       { return c.command.GetClass() }
 func (c *Response) GetHeaders() *SimpleMap { return c.command.GetHeaders() }
 func (c *Response) GetContent() []byte     { return c.command.GetContent() }
 func (c *Response) Size() int              { return c.command.Size() }
+
+func (c *Response) StringWithContentLimit(limit int) string {
+	return c.command.StringWithContentLimit(limit)
+}
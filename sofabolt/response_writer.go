@@ -19,6 +19,7 @@ import (
 	"net"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/sofastack/sofa-common-go/syncpool/bytespool"
 	uatomic "go.uber.org/atomic"
@@ -114,7 +115,11 @@ type SofaResponseWriter struct {
 	conn     net.Conn
 	writer   io.Writer
 	numwrite int
+	written  time.Duration
+	started  time.Time     // the handler was called
+	handled  time.Duration // spent by the handler except writing
 	res      Response
+	wo       WriteOption
 	err      uatomic.Error
 	hijacked uint32
 }
@@ -152,7 +157,11 @@ func (rw *SofaResponseWriter) GetID() uint64 { return atomic.LoadUint64(&rw.id)
 func (rw *SofaResponseWriter) Reset(w io.Writer) *SofaResponseWriter {
 	rw.writer = w
 	rw.numwrite = 0
+	rw.written = 0
+	rw.started = time.Time{}
+	rw.handled = 0
 	rw.res.Reset()
+	rw.wo = WriteOption{}
 	atomic.StoreUint32(&rw.hijacked, 0)
 	return rw
 }
@@ -185,9 +194,10 @@ func (rw *SofaResponseWriter) Write() (int, error) {
 	}
 
 	var err error
+	start := time.Now()
 	dp := rw.pool.Acquire()
 
-	*dp, err = rw.res.Write(&WriteOption{}, (*dp)[:0])
+	*dp, err = rw.res.Write(&rw.wo, (*dp)[:0])
 	if err != nil {
 		rw.pool.Release(dp)
 		return 0, err
@@ -195,6 +205,7 @@ func (rw *SofaResponseWriter) Write() (int, error) {
 
 	rw.numwrite, err = rw.writer.Write(*dp)
 	rw.pool.Release(dp)
+	rw.written = time.Since(start)
 
 	if err != nil {
 		// store the error
@@ -0,0 +1,244 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"time"
+)
+
+// ClientGetter returns a client for the next attempt. *Pool implements it.
+type ClientGetter interface {
+	Get() (*Client, bool)
+}
+
+type ClientGetterFunc func() (*Client, bool)
+
+func (f ClientGetterFunc) Get() (*Client, bool) {
+	return f()
+}
+
+// Retrier retries and hedges idempotent calls across the clients returned by
+// a ClientGetter. A request is sent exactly once unless a retry policy is
+// selected by the request context, by either WithRetryPolicy or
+// WithRetryPolicyName, or the default policy of the retrier.
+type Retrier struct {
+	getter ClientGetter
+
+	options struct {
+		policy   *RetryPolicy
+		policies map[string]*RetryPolicy
+	}
+}
+
+type retrierResult struct {
+	err error
+	req *Request
+	res *Response
+}
+
+func NewRetrier(options ...RetrierOptionSetter) (*Retrier, error) {
+	r := &Retrier{}
+	r.options.policies = make(map[string]*RetryPolicy, 4)
+
+	for _, option := range options {
+		option.Set(r)
+	}
+
+	if err := r.polyfill(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Retrier) polyfill() error {
+	if r.getter == nil {
+		return ErrRetrierNilClientGetter
+	}
+
+	// do not mutate the caller's policies
+	if r.options.policy != nil {
+		np := *r.options.policy
+		np.polyfill()
+		r.options.policy = &np
+	}
+
+	for name, p := range r.options.policies {
+		np := *p
+		np.polyfill()
+		r.options.policies[name] = &np
+	}
+
+	return nil
+}
+
+// GetPolicy returns the retry policy applied to req.
+func (r *Retrier) GetPolicy(req *Request) (*RetryPolicy, bool) {
+	if p, ok := RetryPolicyFromContext(req.GetContext()); ok {
+		// do not mutate the caller's policy
+		np := *p
+		np.polyfill()
+		return &np, true
+	}
+
+	if name, ok := RetryPolicyNameFromContext(req.GetContext()); ok {
+		if p, ok := r.options.policies[name]; ok {
+			return p, true
+		}
+	}
+
+	if r.options.policy != nil {
+		return r.options.policy, true
+	}
+
+	return nil, false
+}
+
+func (r *Retrier) Do(req *Request, res *Response) error {
+	return r.DoTimeout(req, res, zeroDuration)
+}
+
+// DoTimeout sends the request and retries it according to the selected
+// policy. The timeout applies to every single attempt, and the backoff between
+// the attempts is given up once the context of the request is done.
+func (r *Retrier) DoTimeout(req *Request, res *Response, timeout time.Duration) error {
+	p, ok := r.GetPolicy(req)
+	if !ok {
+		client, ok := r.getter.Get()
+		if !ok {
+			return ErrRetrierNoClient
+		}
+		return client.DoTimeout(req, res, timeout)
+	}
+
+	var (
+		err   error
+		retry = p.newBackoff()
+	)
+
+	for attempt := 1; ; attempt++ {
+		if p.isHedged() {
+			err = r.doHedged(p, req, res, timeout)
+		} else {
+			err = r.doOnce(req, res, timeout)
+		}
+
+		if attempt >= p.MaxAttempts || !p.IsRetryable(err, res) {
+			return err
+		}
+
+		if cerr := backoffContext(req.GetContext(), retry.Duration()); cerr != nil {
+			return cerr
+		}
+	}
+}
+
+// backoffContext sleeps d or returns ctx.Err() once ctx is done.
+func backoffContext(ctx context.Context, d time.Duration) error {
+	timer := AcquireTimer(d)
+	defer ReleaseTimer(timer)
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Retrier) doOnce(req *Request, res *Response, timeout time.Duration) error {
+	client, ok := r.getter.Get()
+	if !ok {
+		return ErrRetrierNoClient
+	}
+
+	return client.DoTimeout(req, res, timeout)
+}
+
+func (r *Retrier) doHedged(p *RetryPolicy, req *Request, res *Response, timeout time.Duration) error {
+	var (
+		launched int
+		received int
+		last     retrierResult
+		resultCh = make(chan retrierResult, p.MaxHedges+1)
+		timer    = AcquireTimer(p.HedgeDelay)
+	)
+	defer ReleaseTimer(timer)
+
+	launch := func() bool {
+		client, ok := r.getter.Get()
+		if !ok {
+			return false
+		}
+
+		// every copy owns its request: the client assigns the request id
+		hreq := AcquireRequest()
+		req.CopyTo(hreq)
+		hreq.SetContext(req.GetContext())
+		hres := AcquireResponse()
+		launched++
+
+		go func() {
+			err := client.DoTimeout(hreq, hres, timeout)
+			resultCh <- retrierResult{err: err, req: hreq, res: hres}
+		}()
+		return true
+	}
+
+	if !launch() {
+		return ErrRetrierNoClient
+	}
+
+	for received < launched {
+		select {
+		case rs := <-resultCh:
+			received++
+			if last.req != nil {
+				ReleaseRequest(last.req)
+				ReleaseResponse(last.res)
+			}
+			last = rs
+
+			if rs.err == nil && !p.IsRetryable(nil, rs.res) {
+				goto DONE
+			}
+
+		case <-timer.C:
+			if launched <= p.MaxHedges && launch() {
+				timer.Reset(p.HedgeDelay)
+			}
+		}
+	}
+
+DONE:
+	if last.err == nil {
+		last.res.CopyTo(res)
+	}
+	ReleaseRequest(last.req)
+	ReleaseResponse(last.res)
+
+	// release the losers once they are done
+	if pending := launched - received; pending > 0 {
+		go func() {
+			for i := 0; i < pending; i++ {
+				rs := <-resultCh
+				ReleaseRequest(rs.req)
+				ReleaseResponse(rs.res)
+			}
+		}()
+	}
+
+	return last.err
+}
@@ -0,0 +1,46 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+// RetrierOptionSetter configures a Retrier.
+type RetrierOptionSetter interface {
+	Set(*Retrier)
+}
+
+type RetrierOptionSetterFunc func(*Retrier)
+
+func (f RetrierOptionSetterFunc) Set(r *Retrier) {
+	f(r)
+}
+
+func WithRetrierClientGetter(getter ClientGetter) RetrierOptionSetterFunc {
+	return RetrierOptionSetterFunc(func(r *Retrier) {
+		r.getter = getter
+	})
+}
+
+// WithRetrierPolicy sets the policy applied to requests which do not select
+// one by context or header.
+func WithRetrierPolicy(p *RetryPolicy) RetrierOptionSetterFunc {
+	return RetrierOptionSetterFunc(func(r *Retrier) {
+		r.options.policy = p
+	})
+}
+
+// WithRetrierNamedPolicy registers a policy selectable by WithRetryPolicyName.
+func WithRetrierNamedPolicy(name string, p *RetryPolicy) RetrierOptionSetterFunc {
+	return RetrierOptionSetterFunc(func(r *Retrier) {
+		r.options.policies[name] = p
+	})
+}
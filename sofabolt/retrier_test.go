@@ -0,0 +1,149 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRetrierTestPool(t *testing.T, n int, handler Handler) *Pool {
+	pool := NewPool()
+	for i := 0; i < n; i++ {
+		p0, p1 := net.Pipe()
+		srv, err := NewServer(WithServerHandler(handler))
+		require.Nil(t, err)
+		go func() {
+			srv.ServeConn(p1)
+		}()
+
+		c, err := NewClient(WithClientConn(p0))
+		require.Nil(t, err)
+		pool.Push(c)
+	}
+	return pool
+}
+
+func TestRetrierRetryableStatus(t *testing.T) {
+	var n int32
+	pool := newRetrierTestPool(t, 2, HandlerFunc(func(rw ResponseWriter, req *Request) {
+		if atomic.AddInt32(&n, 1) < 3 {
+			rw.GetResponse().SetStatus(StatusServerThreadPoolBusy)
+		}
+		rw.Write()
+	}))
+
+	r, err := NewRetrier(
+		WithRetrierClientGetter(pool),
+		WithRetrierNamedPolicy("idempotent", &RetryPolicy{MaxAttempts: 3}),
+	)
+	require.Nil(t, err)
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+
+	// no policy selected: send once
+	require.Nil(t, r.DoTimeout(req, res, time.Second))
+	require.Equal(t, StatusServerThreadPoolBusy, res.GetStatus())
+	require.Equal(t, int32(1), atomic.LoadInt32(&n))
+
+	atomic.StoreInt32(&n, 0)
+	req.SetContext(WithRetryPolicyName(context.Background(), "idempotent"))
+	require.Nil(t, r.DoTimeout(req, res, time.Second))
+	require.Equal(t, StatusSuccess, res.GetStatus())
+	require.Equal(t, int32(3), atomic.LoadInt32(&n))
+}
+
+func TestRetrierRetryableError(t *testing.T) {
+	pool := NewPool()
+	closed, err := NewClient(WithClientConn(&net.TCPConn{}))
+	require.Nil(t, err)
+	closed.Close()
+	pool.Push(closed)
+
+	r, err := NewRetrier(WithRetrierClientGetter(pool))
+	require.Nil(t, err)
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	req.SetContext(WithRetryPolicy(context.Background(), &RetryPolicy{
+		MaxAttempts: 2,
+		MinBackoff:  time.Millisecond,
+	}))
+	require.Equal(t, ErrClientWasClosed, r.DoTimeout(req, res, time.Second))
+
+	// the backoff is given up once the context is done
+	ctx, cancel := context.WithCancel(WithRetryPolicy(context.Background(), &RetryPolicy{
+		MaxAttempts: 2,
+		MinBackoff:  time.Minute,
+		MaxBackoff:  time.Minute,
+	}))
+	time.AfterFunc(50*time.Millisecond, cancel)
+	req.SetContext(ctx)
+	started := time.Now()
+	require.Equal(t, context.Canceled, r.DoTimeout(req, res, time.Second))
+	require.Less(t, int64(time.Since(started)), int64(time.Second))
+}
+
+func TestRetrierPolicyNotMutated(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 2}
+	named := &RetryPolicy{}
+	r, err := NewRetrier(
+		WithRetrierClientGetter(NewPool()),
+		WithRetrierPolicy(p),
+		WithRetrierNamedPolicy("idempotent", named),
+	)
+	require.Nil(t, err)
+	require.Equal(t, &RetryPolicy{MaxAttempts: 2}, p)
+	require.Equal(t, &RetryPolicy{}, named)
+
+	got, ok := r.GetPolicy(AcquireRequest())
+	require.True(t, ok)
+	require.Equal(t, 2, got.MaxAttempts)
+	require.Equal(t, 10*time.Millisecond, got.MinBackoff)
+}
+
+func TestRetrierHedged(t *testing.T) {
+	var n int32
+	pool := newRetrierTestPool(t, 2, HandlerFunc(func(rw ResponseWriter, req *Request) {
+		if atomic.AddInt32(&n, 1) == 1 {
+			time.Sleep(500 * time.Millisecond)
+			rw.GetResponse().SetContentString("slow")
+		} else {
+			rw.GetResponse().SetContentString("fast")
+		}
+		rw.Write()
+	}))
+
+	r, err := NewRetrier(
+		WithRetrierClientGetter(pool),
+		WithRetrierPolicy(&RetryPolicy{
+			MaxAttempts: 1,
+			HedgeDelay:  50 * time.Millisecond,
+		}),
+	)
+	require.Nil(t, err)
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	started := time.Now()
+	require.Nil(t, r.DoTimeout(req, res, time.Second))
+	require.Equal(t, "fast", string(res.GetContent()))
+	require.Less(t, int64(time.Since(started)), int64(500*time.Millisecond))
+}
@@ -0,0 +1,144 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jpillora/backoff"
+)
+
+type (
+	retryPolicyContextKey     struct{}
+	retryPolicyNameContextKey struct{}
+)
+
+// WithRetryPolicy returns a copy of ctx which selects the retry policy for the
+// request carrying it.
+func WithRetryPolicy(ctx context.Context, p *RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyContextKey{}, p)
+}
+
+// WithRetryPolicyName returns a copy of ctx which selects the retry policy
+// registered by WithRetrierNamedPolicy for the request carrying it.
+func WithRetryPolicyName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, retryPolicyNameContextKey{}, name)
+}
+
+// RetryPolicyNameFromContext returns the name of the retry policy stored in ctx.
+func RetryPolicyNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(retryPolicyNameContextKey{}).(string)
+	return name, ok && name != ""
+}
+
+// RetryPolicyFromContext returns the retry policy stored in ctx.
+func RetryPolicyFromContext(ctx context.Context) (*RetryPolicy, bool) {
+	p, ok := ctx.Value(retryPolicyContextKey{}).(*RetryPolicy)
+	return p, ok && p != nil
+}
+
+// RetryPolicy describes how an idempotent call is retried and hedged.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one.
+	MaxAttempts       int           `json:"max_attempts"`
+	MinBackoff        time.Duration `json:"min_backoff"`
+	MaxBackoff        time.Duration `json:"max_backoff"`
+	BackoffFactor     float64       `json:"backoff_factor"`
+	BackoffJitter     bool          `json:"backoff_jitter"`
+	RetryableStatuses []Status      `json:"retryable_statuses"`
+	RetryableErrors   []error       `json:"-"`
+	// HedgeDelay sends another copy of the request to the next client if no
+	// response arrives in time. Zero disables hedging.
+	HedgeDelay time.Duration `json:"hedge_delay"`
+	// MaxHedges is the number of extra copies sent within one attempt.
+	MaxHedges int `json:"max_hedges"`
+}
+
+func NewRetryPolicy() *RetryPolicy {
+	p := &RetryPolicy{}
+	p.polyfill()
+	return p
+}
+
+func (p *RetryPolicy) polyfill() {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = 3
+	}
+
+	if p.MinBackoff == 0 {
+		p.MinBackoff = 10 * time.Millisecond
+	}
+
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = 1 * time.Second
+	}
+
+	if p.BackoffFactor == 0 {
+		p.BackoffFactor = 2
+	}
+
+	if p.RetryableStatuses == nil {
+		p.RetryableStatuses = []Status{
+			StatusServerThreadPoolBusy,
+			StatusConnectionClosed,
+		}
+	}
+
+	if p.RetryableErrors == nil {
+		p.RetryableErrors = []error{
+			ErrClientWasClosed,
+			ErrClientNilConnection,
+		}
+	}
+
+	if p.HedgeDelay > 0 && p.MaxHedges == 0 {
+		p.MaxHedges = 1
+	}
+}
+
+func (p *RetryPolicy) newBackoff() *backoff.Backoff {
+	return &backoff.Backoff{
+		Min:    p.MinBackoff,
+		Max:    p.MaxBackoff,
+		Factor: p.BackoffFactor,
+		Jitter: p.BackoffJitter,
+	}
+}
+
+func (p *RetryPolicy) isHedged() bool {
+	return p.HedgeDelay > 0 && p.MaxHedges > 0
+}
+
+// IsRetryable reports whether the result of an attempt is worth to retry.
+func (p *RetryPolicy) IsRetryable(err error, res *Response) bool {
+	if err != nil {
+		for i := range p.RetryableErrors {
+			if errors.Is(err, p.RetryableErrors[i]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	status := res.GetStatus()
+	for i := range p.RetryableStatuses {
+		if status == p.RetryableStatuses[i] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,241 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"sort"
+	"strings"
+)
+
+// The SOFATracer context headers of SOFARPC.
+const (
+	HeaderTraceID    = "rpc_trace_context.sofaTraceId"
+	HeaderRPCID      = "rpc_trace_context.sofaRpcId"
+	HeaderCallerApp  = "rpc_trace_context.sofaCallerApp"
+	HeaderCallerIP   = "rpc_trace_context.sofaCallerIp"
+	HeaderCallerZone = "rpc_trace_context.sofaCallerZone"
+	HeaderSampled    = "rpc_trace_context.samp"
+	HeaderPenAttrs   = "rpc_trace_context.sofaPenAttrs"
+)
+
+const (
+	penAttrsSeparator = '&'
+	penAttrsAssign    = '='
+)
+
+var (
+	penAttrsEscaper   = strings.NewReplacer("%", "%25", "&", "%26", "=", "%3D")
+	penAttrsUnescaper = strings.NewReplacer("%25", "%", "%26", "&", "%3D", "=")
+)
+
+// RPCHeaders is a typed view of the SOFARPC well-known headers. Like SimpleMap.Get,
+// the returned strings refer to the headers and should be copied if they are
+// retained after the command was released.
+type RPCHeaders struct {
+	h *SimpleMap
+}
+
+// NewRPCHeaders returns the view of h.
+func NewRPCHeaders(h *SimpleMap) RPCHeaders { return RPCHeaders{h: h} }
+
+// GetRPCHeaders returns the view of the headers of the request.
+func (c *Request) GetRPCHeaders() RPCHeaders { return NewRPCHeaders(c.GetHeaders()) }
+
+// GetRPCHeaders returns the view of the headers of the response.
+func (c *Response) GetRPCHeaders() RPCHeaders { return NewRPCHeaders(c.GetHeaders()) }
+
+// GetTargetService returns the unique id of the service, which falls back to the
+// header service of the legacy clients.
+func (r RPCHeaders) GetTargetService() string {
+	if s := r.h.Get(headerTargetService); s != "" {
+		return s
+	}
+	return r.h.Get("service")
+}
+
+// SetTargetService sets both sofa_head_target_service and service.
+func (r RPCHeaders) SetTargetService(service string) RPCHeaders {
+	r.h.Set("service", service)
+	r.h.Set(headerTargetService, service)
+	return r
+}
+
+func (r RPCHeaders) GetMethodName() string { return r.h.Get(headerMethodName) }
+
+func (r RPCHeaders) SetMethodName(method string) RPCHeaders {
+	r.h.Set(headerMethodName, method)
+	return r
+}
+
+func (r RPCHeaders) GetTargetApp() string { return r.h.Get(headerTargetApp) }
+
+func (r RPCHeaders) SetTargetApp(app string) RPCHeaders {
+	r.h.Set(headerTargetApp, app)
+	return r
+}
+
+func (r RPCHeaders) GetProtocol() string { return r.h.Get(headerProtocol) }
+
+func (r RPCHeaders) SetProtocol(protocol string) RPCHeaders {
+	r.h.Set(headerProtocol, protocol)
+	return r
+}
+
+func (r RPCHeaders) GetCallerApp() string { return r.h.Get(HeaderCallerApp) }
+
+func (r RPCHeaders) SetCallerApp(app string) RPCHeaders {
+	r.h.Set(HeaderCallerApp, app)
+	return r
+}
+
+func (r RPCHeaders) GetCallerIP() string { return r.h.Get(HeaderCallerIP) }
+
+func (r RPCHeaders) SetCallerIP(ip string) RPCHeaders {
+	r.h.Set(HeaderCallerIP, ip)
+	return r
+}
+
+func (r RPCHeaders) GetCallerZone() string { return r.h.Get(HeaderCallerZone) }
+
+func (r RPCHeaders) SetCallerZone(zone string) RPCHeaders {
+	r.h.Set(HeaderCallerZone, zone)
+	return r
+}
+
+func (r RPCHeaders) GetTraceID() string { return r.h.Get(HeaderTraceID) }
+
+func (r RPCHeaders) SetTraceID(id string) RPCHeaders {
+	r.h.Set(HeaderTraceID, id)
+	return r
+}
+
+func (r RPCHeaders) GetRPCID() string { return r.h.Get(HeaderRPCID) }
+
+func (r RPCHeaders) SetRPCID(id string) RPCHeaders {
+	r.h.Set(HeaderRPCID, id)
+	return r
+}
+
+// IsSampled reports whether the trace is sampled. It's true unless the header is
+// false since SOFATracer samples all by default.
+func (r RPCHeaders) IsSampled() bool {
+	return r.h.Get(HeaderSampled) != "false"
+}
+
+func (r RPCHeaders) SetSampled(sampled bool) RPCHeaders {
+	if sampled {
+		r.h.Set(HeaderSampled, "true")
+	} else {
+		r.h.Set(HeaderSampled, "false")
+	}
+	return r
+}
+
+// GetPenAttrs decodes the penetrating attributes which are encoded as k1=v1&k2=v2&
+// with %, & and = escaped. It returns nil if there are no attributes.
+func (r RPCHeaders) GetPenAttrs() map[string]string {
+	var attrs map[string]string
+	rangePenAttrs(r.h.Get(HeaderPenAttrs), func(k, v string) bool {
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		attrs[k] = v
+		return true
+	})
+	return attrs
+}
+
+// GetPenAttr returns the penetrating attribute of k.
+func (r RPCHeaders) GetPenAttr(k string) (string, bool) {
+	var (
+		value string
+		found bool
+	)
+	rangePenAttrs(r.h.Get(HeaderPenAttrs), func(key, v string) bool {
+		if key == k {
+			value, found = v, true
+			return false
+		}
+		return true
+	})
+	return value, found
+}
+
+// SetPenAttrs replaces the penetrating attributes. The keys are encoded in order,
+// and the header is deleted if attrs is empty.
+func (r RPCHeaders) SetPenAttrs(attrs map[string]string) RPCHeaders {
+	if len(attrs) == 0 {
+		r.h.Del(HeaderPenAttrs)
+		return r
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(penAttrsEscaper.Replace(k))
+		b.WriteByte(penAttrsAssign)
+		b.WriteString(penAttrsEscaper.Replace(attrs[k]))
+		b.WriteByte(penAttrsSeparator)
+	}
+	r.h.Set(HeaderPenAttrs, b.String())
+
+	return r
+}
+
+// SetPenAttr sets the penetrating attribute of k and keeps the others.
+func (r RPCHeaders) SetPenAttr(k, v string) RPCHeaders {
+	attrs := r.GetPenAttrs()
+	if attrs == nil {
+		attrs = make(map[string]string, 1)
+	}
+	attrs[k] = v
+	return r.SetPenAttrs(attrs)
+}
+
+// DelPenAttr deletes the penetrating attribute of k.
+func (r RPCHeaders) DelPenAttr(k string) RPCHeaders {
+	attrs := r.GetPenAttrs()
+	if _, ok := attrs[k]; !ok {
+		return r
+	}
+	delete(attrs, k)
+	return r.SetPenAttrs(attrs)
+}
+
+// rangePenAttrs calls fn with the unescaped attributes in s until fn returns false.
+// The malformed attributes without = are skipped.
+func rangePenAttrs(s string, fn func(k, v string) bool) {
+	for s != "" {
+		var attr string
+		if i := strings.IndexByte(s, penAttrsSeparator); i >= 0 {
+			attr, s = s[:i], s[i+1:]
+		} else {
+			attr, s = s, ""
+		}
+
+		i := strings.IndexByte(attr, penAttrsAssign)
+		if i <= 0 {
+			continue
+		}
+
+		if !fn(penAttrsUnescaper.Replace(attr[:i]), penAttrsUnescaper.Replace(attr[i+1:])) {
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCHeaders(t *testing.T) {
+	var req Request
+	h := req.GetRPCHeaders().
+		SetTargetService("com.alipay.test.UserService:1.0").
+		SetMethodName("echo").
+		SetTargetApp("test").
+		SetProtocol("bolt").
+		SetCallerApp("caller").
+		SetCallerIP("127.0.0.1").
+		SetCallerZone("GZ00A").
+		SetTraceID("0a0fe8f1157000000000110014567").
+		SetRPCID("0.1")
+
+	require.Equal(t, "com.alipay.test.UserService:1.0", h.GetTargetService())
+	require.Equal(t, "com.alipay.test.UserService:1.0", req.GetHeaders().Get("service"))
+	require.Equal(t, "com.alipay.test.UserService:1.0", req.GetHeaders().Get("sofa_head_target_service"))
+	require.Equal(t, "echo", req.GetHeaders().Get("sofa_head_method_name"))
+	require.Equal(t, "test", h.GetTargetApp())
+	require.Equal(t, "bolt", h.GetProtocol())
+	require.Equal(t, "caller", req.GetHeaders().Get("rpc_trace_context.sofaCallerApp"))
+	require.Equal(t, "127.0.0.1", h.GetCallerIP())
+	require.Equal(t, "GZ00A", h.GetCallerZone())
+	require.Equal(t, "0a0fe8f1157000000000110014567", req.GetHeaders().Get("rpc_trace_context.sofaTraceId"))
+	require.Equal(t, "0.1", h.GetRPCID())
+
+	require.True(t, h.IsSampled())
+	h.SetSampled(false)
+	require.False(t, h.IsSampled())
+	h.SetSampled(true)
+	require.True(t, h.IsSampled())
+
+	// falls back to the legacy header
+	var legacy Request
+	legacy.GetHeaders().Set("service", "legacy")
+	require.Equal(t, "legacy", legacy.GetRPCHeaders().GetTargetService())
+
+	// survives the wire
+	req.SetProto(ProtoBOLTV1).SetType(TypeBOLTRequest).SetCMDCode(CMDCodeBOLTRequest)
+	b, err := req.Write(NewWriteOption(), nil)
+	require.Nil(t, err)
+	var got Request
+	_, err = got.Read(NewReadOption(), bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Equal(t, "echo", got.GetRPCHeaders().GetMethodName())
+	require.Equal(t, "caller", got.GetRPCHeaders().GetCallerApp())
+	require.Equal(t, "0a0fe8f1157000000000110014567", got.GetRPCHeaders().GetTraceID())
+}
+
+func TestRPCHeadersPenAttrs(t *testing.T) {
+	var res Response
+	h := res.GetRPCHeaders()
+	require.Nil(t, h.GetPenAttrs())
+	_, ok := h.GetPenAttr("a")
+	require.False(t, ok)
+
+	h.SetPenAttrs(map[string]string{"b": "2", "a": "1", "c&d": "x=y%z"})
+	require.Equal(t, "a=1&b=2&c%26d=x%3Dy%25z&", res.GetHeaders().Get("rpc_trace_context.sofaPenAttrs"))
+	require.Equal(t, map[string]string{"a": "1", "b": "2", "c&d": "x=y%z"}, h.GetPenAttrs())
+
+	v, ok := h.GetPenAttr("c&d")
+	require.True(t, ok)
+	require.Equal(t, "x=y%z", v)
+
+	h.SetPenAttr("a", "3").SetPenAttr("e", "")
+	require.Equal(t, "a=3&b=2&c%26d=x%3Dy%25z&e=&", res.GetHeaders().Get("rpc_trace_context.sofaPenAttrs"))
+	v, ok = h.GetPenAttr("e")
+	require.True(t, ok)
+	require.Equal(t, "", v)
+
+	h.DelPenAttr("c&d").DelPenAttr("missing")
+	require.Equal(t, map[string]string{"a": "3", "b": "2", "e": ""}, h.GetPenAttrs())
+
+	// decodes the attrs of the other implementations leniently
+	res.GetHeaders().Set("rpc_trace_context.sofaPenAttrs", "k=v&&malformed&=empty&x=1")
+	require.Equal(t, map[string]string{"k": "v", "x": "1"}, h.GetPenAttrs())
+
+	h.SetPenAttrs(nil)
+	require.Equal(t, "", res.GetHeaders().Get("rpc_trace_context.sofaPenAttrs"))
+}
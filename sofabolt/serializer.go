@@ -0,0 +1,250 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/sofastack/sofa-hessian-go/sofahessian"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrSerializerNotFound    = errors.New("sofabolt: serializer of the codec is not registered")
+	ErrSerializerUnsupported = errors.New("sofabolt: serializer does not support the type")
+	ErrSerializerArgs        = errors.New("sofabolt: serializer cannot encode multiple args")
+)
+
+// Serializer encodes and decodes the content of a codec.
+type Serializer interface {
+	// Marshal appends the encoded v to dst.
+	Marshal(dst []byte, v interface{}) ([]byte, error)
+	// Unmarshal decodes b to v which is a pointer.
+	Unmarshal(b []byte, v interface{}) error
+}
+
+// ArgsSerializer is implemented by the serializers which encode multiple args
+// in one content, e.g. a JSON array. Otherwise a method takes at most one arg.
+type ArgsSerializer interface {
+	MarshalArgs(dst []byte, args []interface{}) ([]byte, error)
+	// UnmarshalArgs decodes b to args which are pointers.
+	UnmarshalArgs(b []byte, args []interface{}) error
+}
+
+var serializers struct {
+	sync.RWMutex
+	s [256]Serializer
+}
+
+// nolint
+func init() {
+	RegisterSerializer(CodecHessian2, hessianSerializer{})
+	RegisterSerializer(CodecProtobuf, protoSerializer{})
+	RegisterSerializer(CodecJSON, jsonSerializer{})
+}
+
+// RegisterSerializer registers s as the serializer of codec and replaces the
+// previous one. It's used by SetBody, GetBody, Client.InvokeCodec and the services
+// registered by Server.RegisterService.
+func RegisterSerializer(codec Codec, s Serializer) {
+	serializers.Lock()
+	serializers.s[codec] = s
+	serializers.Unlock()
+}
+
+// GetSerializer returns the serializer of codec.
+func GetSerializer(codec Codec) (Serializer, bool) {
+	serializers.RLock()
+	s := serializers.s[codec]
+	serializers.RUnlock()
+	return s, s != nil
+}
+
+// SetBody marshals v to the content by the serializer of the codec.
+func (c *Request) SetBody(v interface{}) error {
+	s, ok := GetSerializer(c.GetCodec())
+	if !ok {
+		return ErrSerializerNotFound
+	}
+
+	var err error
+	c.command.content, err = s.Marshal(c.command.content[:0], v)
+	return err
+}
+
+// GetBody unmarshals the content to v by the serializer of the codec.
+func (c *Request) GetBody(v interface{}) error {
+	s, ok := GetSerializer(c.GetCodec())
+	if !ok {
+		return ErrSerializerNotFound
+	}
+	return s.Unmarshal(c.GetContent(), v)
+}
+
+// SetBody marshals v to the content by the serializer of the codec.
+func (c *Response) SetBody(v interface{}) error {
+	s, ok := GetSerializer(c.GetCodec())
+	if !ok {
+		return ErrSerializerNotFound
+	}
+
+	var err error
+	c.command.content, err = s.Marshal(c.command.content[:0], v)
+	return err
+}
+
+// GetBody unmarshals the content to v by the serializer of the codec.
+func (c *Response) GetBody(v interface{}) error {
+	s, ok := GetSerializer(c.GetCodec())
+	if !ok {
+		return ErrSerializerNotFound
+	}
+	return s.Unmarshal(c.GetContent(), v)
+}
+
+// marshalArgs encodes args as the content of a method call.
+func marshalArgs(s Serializer, dst []byte, args []interface{}) ([]byte, error) {
+	if as, ok := s.(ArgsSerializer); ok {
+		return as.MarshalArgs(dst, args)
+	}
+
+	switch len(args) {
+	case 0:
+		return dst, nil
+	case 1:
+		return s.Marshal(dst, args[0])
+	default:
+		return dst, ErrSerializerArgs
+	}
+}
+
+// unmarshalArgs decodes the content of a method call to args which are pointers.
+func unmarshalArgs(s Serializer, b []byte, args []interface{}) error {
+	if as, ok := s.(ArgsSerializer); ok {
+		return as.UnmarshalArgs(b, args)
+	}
+
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return s.Unmarshal(b, args[0])
+	default:
+		return ErrSerializerArgs
+	}
+}
+
+// hessianSerializer encodes a single value in hessian 3.x v2 which SOFARPC uses.
+type hessianSerializer struct{}
+
+func (hessianSerializer) Marshal(dst []byte, v interface{}) ([]byte, error) {
+	ectx := sofahessian.AcquireHessianEncodeContext().SetVersion(sofahessian.Hessian3xV2)
+	dst, err := sofahessian.EncodeToHessian3V2(ectx, dst, v)
+	sofahessian.ReleaseHessianEncodeContext(ectx)
+	return dst, err
+}
+
+func (hessianSerializer) Unmarshal(b []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return ErrSerializerUnsupported
+	}
+
+	dctx := sofahessian.AcquireHessianDecodeContext().
+		SetVersion(sofahessian.Hessian3xV2).
+		SetClassRegistry(&sofaregistry)
+	bbr := sofahessian.AcquireBytesBufioReader(b)
+	x, err := sofahessian.DecodeHessian3V2(dctx, bbr.GetBufioReader())
+	sofahessian.ReleaseBytesBufioReader(bbr)
+	sofahessian.ReleaseHessianDecodeContext(dctx)
+	if err != nil {
+		return err
+	}
+
+	return assignValue(rv.Elem(), x)
+}
+
+// assignValue converts the decoded x and sets it to dst.
+func assignValue(dst reflect.Value, x interface{}) error {
+	xv, err := convertArg(x, dst.Type())
+	if err != nil {
+		return err
+	}
+	dst.Set(xv)
+	return nil
+}
+
+type protoSerializer struct{}
+
+func (protoSerializer) Marshal(dst []byte, v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return dst, ErrSerializerUnsupported
+	}
+	return proto.MarshalOptions{}.MarshalAppend(dst, m)
+}
+
+func (protoSerializer) Unmarshal(b []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return ErrSerializerUnsupported
+	}
+	return proto.Unmarshal(b, m)
+}
+
+// jsonSerializer encodes multiple args as a JSON array.
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(dst []byte, v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, b...), nil
+}
+
+func (jsonSerializer) Unmarshal(b []byte, v interface{}) error {
+	return json.Unmarshal(b, v)
+}
+
+func (s jsonSerializer) MarshalArgs(dst []byte, args []interface{}) ([]byte, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	return s.Marshal(dst, args)
+}
+
+func (jsonSerializer) UnmarshalArgs(b []byte, args []interface{}) error {
+	var raws []json.RawMessage
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &raws); err != nil {
+			return err
+		}
+	}
+
+	if len(raws) != len(args) {
+		return ErrServiceArgsMismatch
+	}
+
+	for i := range args {
+		if err := json.Unmarshal(raws[i], args[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
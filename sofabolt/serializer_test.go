@@ -0,0 +1,141 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const codecText Codec = 100
+
+// textSerializer encodes strings as is.
+type textSerializer struct{}
+
+func (textSerializer) Marshal(dst []byte, v interface{}) ([]byte, error) {
+	return append(dst, fmt.Sprint(v)...), nil
+}
+
+func (textSerializer) Unmarshal(b []byte, v interface{}) error {
+	s, ok := v.(*string)
+	if !ok {
+		return ErrSerializerUnsupported
+	}
+	*s = string(b)
+	return nil
+}
+
+type textService struct{}
+
+func (textService) Upper(s string) string { return strings.ToUpper(s) }
+
+func (textService) Echo(s *wrapperspb.StringValue) *wrapperspb.StringValue { return s }
+
+func init() {
+	RegisterSerializer(codecText, textSerializer{})
+}
+
+func TestSerializerBody(t *testing.T) {
+	s, ok := GetSerializer(CodecHessian2)
+	require.True(t, ok)
+	require.Equal(t, hessianSerializer{}, s)
+
+	_, ok = GetSerializer(Codec(101))
+	require.False(t, ok)
+
+	var req Request
+	req.SetCodec(CodecHessian2)
+	require.Nil(t, req.SetBody(&sofaTestUser{Name: "foo", Age: 1}))
+	var u sofaTestUser
+	require.Nil(t, req.GetBody(&u))
+	require.Equal(t, sofaTestUser{Name: "foo", Age: 1}, u)
+
+	var res Response
+	res.SetCodec(CodecProtobuf)
+	require.Nil(t, res.SetBody(&wrapperspb.StringValue{Value: "hello"}))
+	var sv wrapperspb.StringValue
+	require.Nil(t, res.GetBody(&sv))
+	require.Equal(t, "hello", sv.GetValue())
+	require.Equal(t, ErrSerializerUnsupported, res.SetBody("hello"))
+
+	res.SetCodec(codecText)
+	require.Nil(t, res.SetBody(42))
+	var str string
+	require.Nil(t, res.GetBody(&str))
+	require.Equal(t, "42", str)
+
+	res.SetCodec(Codec(101))
+	require.Equal(t, ErrSerializerNotFound, res.SetBody("hello"))
+	require.Equal(t, ErrSerializerNotFound, res.GetBody(&str))
+}
+
+func TestClientInvokeCodec(t *testing.T) {
+	p0, p1 := net.Pipe()
+	srv, err := NewServer(WithServerHandler(HandlerFunc(func(rw ResponseWriter, r *Request) {
+		rw.GetResponse().SetStatus(StatusNoProcessor)
+		rw.Write()
+	})))
+	require.Nil(t, err)
+	require.Nil(t, srv.RegisterService("com.alipay.test.TextService:1.0", textService{}))
+	require.Nil(t, srv.RegisterService("com.alipay.test.UserService:1.0", &userService{}))
+
+	go func() {
+		srv.ServeConn(p1)
+	}()
+
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var s string
+	require.Nil(t, c.InvokeCodec(ctx, codecText, "com.alipay.test.TextService:1.0", "upper", &s, "hello"))
+	require.Equal(t, "HELLO", s)
+
+	require.Equal(t, ErrSerializerArgs,
+		c.InvokeCodec(ctx, codecText, "com.alipay.test.TextService:1.0", "upper", &s, "a", "b"))
+
+	var sv wrapperspb.StringValue
+	require.Nil(t, c.InvokeCodec(ctx, CodecProtobuf, "com.alipay.test.TextService:1.0", "echo",
+		&sv, &wrapperspb.StringValue{Value: "hello"}))
+	require.Equal(t, "hello", sv.GetValue())
+
+	sv.Reset()
+	require.Nil(t, c.InvokeProto(ctx, "com.alipay.test.TextService:1.0", "echo",
+		&wrapperspb.StringValue{Value: "proto"}, &sv))
+	require.Equal(t, "proto", sv.GetValue())
+
+	var n int
+	require.Nil(t, c.InvokeCodec(ctx, CodecHessian2, "com.alipay.test.UserService:1.0", "add", &n, int32(1), int32(2)))
+	require.Equal(t, 3, n)
+
+	require.Nil(t, c.InvokeCodec(ctx, CodecJSON, "com.alipay.test.UserService:1.0", "add", &n, 2, 3))
+	require.Equal(t, 5, n)
+
+	err = c.InvokeCodec(ctx, CodecJSON, "com.alipay.test.UserService:1.0", "fail", nil)
+	require.True(t, errors.Is(err, ErrRemoteServerException))
+
+	require.Equal(t, ErrSerializerNotFound,
+		c.InvokeCodec(ctx, Codec(101), "com.alipay.test.UserService:1.0", "add", &n, 1, 2))
+}
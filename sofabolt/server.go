@@ -21,6 +21,7 @@ import (
     "sync/atomic"
     "time"
 
+    "github.com/sofastack/sofa-bolt-go/sofabolt/conn/capconn"
     stateconn "github.com/sofastack/sofa-bolt-go/sofabolt/conn/stateconn"
     workerpool "github.com/sofastack/sofa-common-go/syncpool/fast-workerpool"
     bufiorw "github.com/sofastack/sofa-common-go/writer/bufiorw"
@@ -39,15 +40,26 @@ type Server struct {
 
     handler   Handler
     onhandler ServerOnEventHandler
+    accesslog *accessLog
+    services  serviceRegistry
+    protos    ProtoServeMux
 
     options struct {
-        async             bool
-        readTimeout       time.Duration
-        writeTimeout      time.Duration
-        idleTimeout       time.Duration
-        flushInterval     time.Duration
-        maxPendingCommand int
-        maxConnections    int
+        async                bool
+        readTimeout          time.Duration
+        writeTimeout         time.Duration
+        idleTimeout          time.Duration
+        flushInterval        time.Duration
+        maxPendingCommand    int
+        maxConnections       int
+        accessLog            *AccessLogOptions
+        slow                 *SlowOptions
+        capture              capconn.Recorder
+        compression          Compression
+        compressionThreshold int
+        headers              SimpleMapOptions
+        maxDecompressedSize  int
+        tracer               ServerTracer
     }
 
     metrics *ServerMetrics
@@ -75,10 +87,6 @@ func NewServer(options ...serverOptionSetter) (*Server, error) {
 func (srv *Server) GetMetrics() *ServerMetrics { return srv.metrics }
 
 func (srv *Server) polyfill() error {
-    if srv.handler == nil {
-        return ErrServerHandler
-    }
-
     if srv.onhandler == nil {
         srv.onhandler = DummyServerOnEventHandler
     }
@@ -91,6 +99,8 @@ func (srv *Server) polyfill() error {
         srv.metrics = &ServerMetrics{}
     }
 
+    srv.accesslog = newAccessLog(srv.options.accessLog)
+
     srv.conns = make(map[net.Conn]struct{}, srv.options.maxConnections)
 
     // worker pool
@@ -158,6 +168,9 @@ func (srv *Server) Serve(ln net.Listener) error {
 func (srv *Server) ServeConn(conn net.Conn) error {
     srv.metrics.addConnections(1)
     srv.metrics.addPendingConnections(1)
+    if srv.options.capture != nil {
+        conn = NewCaptureConn(conn, srv.options.capture)
+    }
     sc := stateconn.AcquireConn(conn)
     srv.addConn(sc)
 
@@ -196,6 +209,8 @@ func (srv *Server) setConnIdleTimeout(conn net.Conn) error {
 func (srv *Server) serveConn(conn net.Conn) (hijacked bool, err error) {
     var (
         req           Request
+        ro            = NewReadOption().SetHeadersOptions(srv.options.headers).
+                            SetMaxDecompressedSize(srv.options.maxDecompressedSize)
         nr            int
         requests      uint64
         rw            *SofaResponseWriter
@@ -235,12 +250,18 @@ func (srv *Server) serveConn(conn net.Conn) (hijacked bool, err error) {
 READLOOP:
     for {
         req.Reset()
-        if nr, err = req.Read(&ReadOption{}, br); err != nil {
+        if nr, err = req.Read(ro, br); err != nil {
             break READLOOP
         }
 
         srv.metrics.addBytesRead(int64(nr))
+        if compressed, original := ro.GetCompressedSize(); compressed > 0 {
+            srv.metrics.ObserveCompression(compressed, original)
+        }
         requests++
+        if srv.options.slow != nil {
+            req.received = time.Now()
+        }
 
         if req.GetType() != TypeBOLTRequest &&
             req.GetType() != TypeBOLTRequestOneWay &&
@@ -251,10 +272,16 @@ READLOOP:
 
         hijacked = srv.HandleCommand(&wg, conn, bw, rw, &req)
 
+        flushStarted := time.Now()
         if lastFlushTime, err = srv.flushWrite(conn, bw, lastFlushTime); err != nil {
             break READLOOP
         }
 
+        if !srv.options.async {
+            // the response is buffered by the sync handler, it's flushed here
+            srv.detectSlow(rw, &req, rw.written+time.Since(flushStarted))
+        }
+
         if hijacked {
             break READLOOP
         }
@@ -310,6 +337,7 @@ func (srv *Server) HandleCommand(wg *sync.WaitGroup, conn net.Conn, bw *bufiorw.
 func (srv *Server) handleCommandAsync(wg *sync.WaitGroup, conn net.Conn, rw *SofaResponseWriter, raw *Request) {
     req := AcquireRequest()
     req.CopyCommand(&raw.command)
+    req.received = raw.received
 
     wg.Add(1)
     go func(id uint64) {
@@ -323,8 +351,10 @@ func (srv *Server) doHandleCommandAsync(conn net.Conn, id uint64, req *Request)
     rw := AcquireSofaResponseWriter(conn, conn)
     rw.id = id
     rw.Derive(req)
+    srv.deriveCompression(rw, req)
 
-    srv.serveCommand(rw, req)
+    started, elapsed := srv.serveCommand(rw, req)
+    written := rw.written
 
     if rw.numwrite == 0 && req.GetType() != TypeBOLTRequestOneWay &&
         req.GetType() != TypeTBRemotingOneWay {
@@ -335,13 +365,18 @@ func (srv *Server) doHandleCommandAsync(conn net.Conn, id uint64, req *Request)
         srv.metrics.addBytesWrite(int64(rw.numwrite))
     }
 
+    srv.finishCommand(rw, req, started, elapsed, written)
+    srv.detectSlow(rw, req, rw.written)
+
     ReleaseSofaResponseWriter(rw)
 }
 
 // nolint
 func (srv *Server) handleCommandSync(bw *bufiorw.Writer, rw *SofaResponseWriter, req *Request) bool {
     rw.Reset(bw).Derive(req)
-    srv.serveCommand(rw, req)
+    srv.deriveCompression(rw, req)
+    started, elapsed := srv.serveCommand(rw, req)
+    written := rw.written
     if rw.numwrite == 0 && req.GetType() != TypeBOLTRequestOneWay &&
         req.GetType() != TypeTBRemotingOneWay {
         // write once to avoid nil response
@@ -351,16 +386,92 @@ func (srv *Server) handleCommandSync(bw *bufiorw.Writer, rw *SofaResponseWriter,
         srv.metrics.addBytesWrite(int64(rw.numwrite))
     }
 
+    srv.finishCommand(rw, req, started, elapsed, written)
+
     return rw.IsHijacked()
 }
 
-func (srv *Server) serveCommand(rw ResponseWriter, req *Request) {
+func (srv *Server) serveCommand(rw ResponseWriter, req *Request) (time.Time, time.Duration) {
     srv.metrics.addCommands(1)
     srv.metrics.addPendingCommands(1)
+    srv.metrics.ObserveRequestSize(req.Size())
+
+    var finish func()
+    if srv.options.tracer != nil {
+        finish = srv.options.tracer.TraceServer(rw, req)
+    }
+
+    start := time.Now()
+    srv.serveSofaBOLT(rw, req)
+    elapsed := time.Since(start)
 
-    srv.handler.ServeSofaBOLT(rw, req)
+    if finish != nil {
+        finish()
+    }
+    srv.metrics.ObserveLatency(rw.GetResponse().GetStatus(), elapsed)
 
     srv.metrics.addPendingCommands(-1)
+
+    return start, elapsed
+}
+
+// deriveCompression compresses the response if the request accepts the compression
+// of the server, and accepts it in turn so the client compresses the requests.
+func (srv *Server) deriveCompression(rw *SofaResponseWriter, req *Request) {
+    if acceptsCompression(&req.command, srv.options.compression) {
+        rw.wo.SetCompression(srv.options.compression, srv.options.compressionThreshold).
+            SetAcceptEncoding(srv.options.compression)
+    }
+}
+
+// finishCommand records the served command. written is the time spent writing
+// the response within the handler.
+func (srv *Server) finishCommand(rw *SofaResponseWriter, req *Request,
+    started time.Time, elapsed, written time.Duration) {
+    if rw.numwrite > 0 {
+        srv.metrics.ObserveResponseSize(rw.numwrite)
+    }
+    if compressed, original := rw.wo.GetCompressedSize(); compressed > 0 {
+        srv.metrics.ObserveCompression(compressed, original)
+    }
+
+    if srv.accesslog != nil {
+        srv.accesslog.log(SideServer, rw.GetConn(), req, rw.GetResponse().GetStatus(),
+            req.Size(), rw.numwrite, elapsed, rw.GetWriteError())
+    }
+
+    rw.started = started
+    rw.handled = elapsed - written
+}
+
+// detectSlow reports the served command if it's slow. flush is the time spent
+// writing and flushing the response.
+func (srv *Server) detectSlow(rw *SofaResponseWriter, req *Request, flush time.Duration) {
+    o := srv.options.slow
+    if o == nil || req.received.IsZero() {
+        return
+    }
+
+    total := time.Since(req.received)
+    if total < o.Threshold {
+        return
+    }
+
+    sr := &SlowRequest{
+        Side:     SideServer,
+        Remote:   remoteAddr(rw.GetConn()),
+        Request:  o.dump(req),
+        Response: o.dump(rw.GetResponse()),
+        Queue:    rw.started.Sub(req.received),
+        Handler:  rw.handled,
+        Flush:    flush,
+        Total:    total,
+    }
+    if o.OnSlow != nil {
+        o.OnSlow(sr)
+    }
+    srv.onhandler(srv, nil, NewServerEventContext(ServerSlowRequestEvent).
+        SetConn(rw.GetConn()).SetReq(req).SetRes(rw.GetResponse()).SetSlow(sr))
 }
 
 func (srv *Server) addListener(ln net.Listener) {
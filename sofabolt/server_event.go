@@ -24,12 +24,14 @@ const (
 	ServerWorkerPoolOverflowEvent ServerEvent = 1
 	ServerConnErrorEvent          ServerEvent = 2
 	ServerConnHijackedEvent       ServerEvent = 3
+	ServerSlowRequestEvent        ServerEvent = 4
 )
 
 type ServerEventContext struct {
 	req   *Request
 	res   *Response
 	conn  net.Conn
+	slow  *SlowRequest
 	event ServerEvent
 }
 
@@ -37,7 +39,11 @@ func NewServerEventContext(event ServerEvent) *ServerEventContext {
 	return &ServerEventContext{event: event}
 }
 
-func (s ServerEventContext) GetType() ServerEvent { return s.event }
+func (s ServerEventContext) GetType() ServerEvent         { return s.event }
+func (s ServerEventContext) GetConn() net.Conn            { return s.conn }
+func (s ServerEventContext) GetReq() *Request             { return s.req }
+func (s ServerEventContext) GetRes() *Response            { return s.res }
+func (s ServerEventContext) GetSlowRequest() *SlowRequest { return s.slow }
 
 func (sec *ServerEventContext) SetConn(conn net.Conn) *ServerEventContext {
 	sec.conn = conn
@@ -54,6 +60,11 @@ func (sec *ServerEventContext) SetRes(res *Response) *ServerEventContext {
 	return sec
 }
 
+func (sec *ServerEventContext) SetSlow(sr *SlowRequest) *ServerEventContext {
+	sec.slow = sr
+	return sec
+}
+
 type ServerOnEventHandler func(*Server, error, *ServerEventContext)
 
 var DummyServerOnEventHandler = ServerOnEventHandler(func(*Server, error, *ServerEventContext) {
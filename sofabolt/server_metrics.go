@@ -13,7 +13,12 @@
 
 package sofabolt
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt/histogram"
+)
 
 type ServerMetrics struct {
 	numwrite           int64
@@ -22,6 +27,14 @@ type ServerMetrics struct {
 	pendingcommands    int64
 	connections        int64
 	pendingconnections int64
+
+	// latency in nanoseconds and sizes in bytes
+	latency         histogram.Histogram
+	latencyByStatus histogram.Map
+	requestSize     histogram.Histogram
+	responseSize    histogram.Histogram
+	// compressed size in percent of the original size
+	compressionRatio histogram.Histogram
 }
 
 func (sm *ServerMetrics) GetBytesRead() int64 {
@@ -71,3 +84,57 @@ func (sm *ServerMetrics) addCommands(n int64) {
 func (sm *ServerMetrics) addPendingCommands(n int64) {
 	atomic.AddInt64(&sm.pendingcommands, n)
 }
+
+// GetLatency returns the histogram of handler latency in nanoseconds.
+func (sm *ServerMetrics) GetLatency() *histogram.Histogram {
+	return &sm.latency
+}
+
+// GetLatencyByStatus returns the histogram of handler latency of the status.
+func (sm *ServerMetrics) GetLatencyByStatus(status Status) *histogram.Histogram {
+	return sm.latencyByStatus.Get(uint64(status))
+}
+
+// RangeLatencyByStatus calls fn with the latency histogram of every seen status.
+func (sm *ServerMetrics) RangeLatencyByStatus(fn func(status Status, h *histogram.Histogram) bool) {
+	sm.latencyByStatus.Range(func(key uint64, h *histogram.Histogram) bool {
+		return fn(Status(key), h)
+	})
+}
+
+// GetRequestSize returns the histogram of request size in bytes.
+func (sm *ServerMetrics) GetRequestSize() *histogram.Histogram {
+	return &sm.requestSize
+}
+
+// GetResponseSize returns the histogram of response size in bytes.
+func (sm *ServerMetrics) GetResponseSize() *histogram.Histogram {
+	return &sm.responseSize
+}
+
+// ObserveLatency records the handler latency of a request.
+func (sm *ServerMetrics) ObserveLatency(status Status, d time.Duration) {
+	sm.latency.Record(int64(d))
+	sm.latencyByStatus.Get(uint64(status)).Record(int64(d))
+}
+
+// ObserveRequestSize records the decoded size of a request.
+func (sm *ServerMetrics) ObserveRequestSize(n int) {
+	sm.requestSize.Record(int64(n))
+}
+
+// ObserveResponseSize records the encoded size of a response.
+func (sm *ServerMetrics) ObserveResponseSize(n int) {
+	sm.responseSize.Record(int64(n))
+}
+
+// GetCompressionRatio returns the histogram of the compressed size in percent of
+// the original size of the content read and written.
+func (sm *ServerMetrics) GetCompressionRatio() *histogram.Histogram {
+	return &sm.compressionRatio
+}
+
+// ObserveCompression records the compressed and the original size of a content.
+func (sm *ServerMetrics) ObserveCompression(compressed, original int) {
+	sm.compressionRatio.Record(compressionRatio(compressed, original))
+}
@@ -13,7 +13,11 @@
 
 package sofabolt
 
-import "time"
+import (
+	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt/conn/capconn"
+)
 
 // serverOptionSetter configures a Server.
 type serverOptionSetter interface {
@@ -70,3 +74,57 @@ func WithServerOnEventHandler(e ServerOnEventHandler) serverOptionSetter {
 		srv.onhandler = e
 	})
 }
+
+// WithServerAccessLog emits one record per served request.
+func WithServerAccessLog(o *AccessLogOptions) serverOptionSetter {
+	return serverOptionSetterFunc(func(srv *Server) {
+		srv.options.accessLog = o
+	})
+}
+
+// WithServerSlow reports the requests slower than the threshold through the
+// callback and the ServerSlowRequestEvent.
+func WithServerSlow(o *SlowOptions) serverOptionSetter {
+	return serverOptionSetterFunc(func(srv *Server) {
+		srv.options.slow = o
+	})
+}
+
+// WithServerCapture records the commands of every connection to r.
+func WithServerCapture(r capconn.Recorder) serverOptionSetter {
+	return serverOptionSetterFunc(func(srv *Server) {
+		srv.options.capture = r
+	})
+}
+
+// WithServerCompression compresses the content of the responses of at least threshold
+// bytes by c if the request accepts c. The compressed requests are always accepted.
+func WithServerCompression(c Compression, threshold int) serverOptionSetter {
+	return serverOptionSetterFunc(func(srv *Server) {
+		srv.options.compression = c
+		srv.options.compressionThreshold = threshold
+	})
+}
+
+// WithServerHeadersOptions configures the headers of the requests read, e.g. to
+// preserve the order and the duplicate keys for a proxy, or to limit the entries.
+func WithServerHeadersOptions(o SimpleMapOptions) serverOptionSetter {
+	return serverOptionSetterFunc(func(srv *Server) {
+		srv.options.headers = o
+	})
+}
+
+// WithServerTracer traces every request served by t, see the package tracing.
+func WithServerTracer(t ServerTracer) serverOptionSetter {
+	return serverOptionSetterFunc(func(srv *Server) {
+		srv.options.tracer = t
+	})
+}
+
+// WithServerMaxDecompressedSize limits the decompressed content of the requests,
+// DefaultMaxDecompressedSize by default.
+func WithServerMaxDecompressedSize(n int) serverOptionSetter {
+	return serverOptionSetterFunc(func(srv *Server) {
+		srv.options.maxDecompressedSize = n
+	})
+}
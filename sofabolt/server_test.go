@@ -85,6 +85,10 @@ func TestServer(t *testing.T) {
 	require.Equal(t, int64(100), srv.GetMetrics().GetCommands())
 	require.Equal(t, int64(1), srv.GetMetrics().GetConnections())
 	require.Equal(t, int64(0), srv.GetMetrics().GetPendingCommands())
+	require.Equal(t, int64(100), srv.GetMetrics().GetLatency().GetCount())
+	require.Equal(t, int64(100), srv.GetMetrics().GetLatencyByStatus(Status(200)).GetCount())
+	require.Equal(t, int64(100), srv.GetMetrics().GetRequestSize().GetCount())
+	require.Equal(t, int64(9300), srv.GetMetrics().GetResponseSize().GetSum())
 }
 
 func TestServerParallelWrite(t *testing.T) {
@@ -255,3 +259,41 @@ func TestServerSeeEOF(t *testing.T) {
 	err = srv.ServeConn(p0)
 	require.Equal(t, io.EOF, err)
 }
+
+func TestServerHeadersOptions(t *testing.T) {
+	srv, err := NewServer(
+		WithServerHeadersOptions(SimpleMapOptions{Ordered: true, MaxEntries: 8}),
+		WithServerAsync(true),
+		WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			rw.GetResponse().SetContentString(fmt.Sprint(req.GetHeaders().GetAll("k")))
+			// nolint
+			rw.Write()
+		})),
+	)
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go func() {
+		// nolint
+		srv.ServeConn(p0)
+	}()
+
+	c, err := NewClient(WithClientConn(p1))
+	require.Nil(t, err)
+	defer c.Close()
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	defer ReleaseRequest(req)
+	defer ReleaseResponse(res)
+
+	req.GetHeaders().SetOptions(SimpleMapOptions{Ordered: true})
+	req.GetHeaders().Add("k", "1").Add("k", "2")
+	require.Nil(t, c.DoTimeout(req, res, time.Second))
+	require.Equal(t, "[1 2]", string(res.GetContent()))
+
+	for i := 0; i < 8; i++ {
+		req.GetHeaders().Add("k", "overflow")
+	}
+	require.NotNil(t, c.DoTimeout(req, res, time.Second))
+}
@@ -25,11 +25,12 @@ func _() {
 	_ = x[ServerWorkerPoolOverflowEvent-1]
 	_ = x[ServerConnErrorEvent-2]
 	_ = x[ServerConnHijackedEvent-3]
+	_ = x[ServerSlowRequestEvent-4]
 }
 
-const _ServerEvent_name = "ServerTemporaryAcceptEventServerWorkerPoolOverflowEventServerConnErrorEventServerConnHijackedEvent"
+const _ServerEvent_name = "ServerTemporaryAcceptEventServerWorkerPoolOverflowEventServerConnErrorEventServerConnHijackedEventServerSlowRequestEvent"
 
-var _ServerEvent_index = [...]uint8{0, 26, 55, 75, 98}
+var _ServerEvent_index = [...]uint8{0, 26, 55, 75, 98, 120}
 
 func (i ServerEvent) String() string {
 	if i >= ServerEvent(len(_ServerEvent_index)-1) {
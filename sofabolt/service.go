@@ -0,0 +1,407 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sofastack/sofa-hessian-go/javaobject"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrServiceRegistered   = errors.New("sofabolt: service was registered")
+	ErrServiceNoMethods    = errors.New("sofabolt: service has no suitable methods")
+	ErrServiceArgsMismatch = errors.New("sofabolt: number of args mismatch")
+)
+
+var (
+	typeOfContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+	typeOfError   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+type serviceMethod struct {
+	method   reflect.Method
+	withctx  bool
+	args     []reflect.Type
+	withres  bool
+	witherr  bool
+	javaname string
+}
+
+type service struct {
+	uniqueID string
+	rcvr     reflect.Value
+	methods  map[string]*serviceMethod
+}
+
+type serviceRegistry struct {
+	sync.RWMutex
+	services map[string]*service
+}
+
+// RegisterService exports the methods of impl as the SOFARPC service uniqueID
+// (e.g. "com.alipay.FooService:1.0"). An exported method is served if it looks like
+//
+//	func (t *T) MethodName([ctx context.Context,] args...) [(reply, error) | reply | error]
+//
+// It's served as both "MethodName" and "methodName" to match the java naming.
+// Args are decoded into the parameter types and an error returned by the method
+// is written as a java RpcServerException. Requests of the service to an unknown
+// method are responded with StatusNoProcessor, requests of other services are
+// passed to the handler, or responded with StatusNoProcessor without a handler. Requests of other codecs than hessian2 are decoded and
+// encoded by the registered serializers, e.g. the args of CodecJSON are a JSON array.
+func (srv *Server) RegisterService(uniqueID string, impl interface{}) error {
+	s := &service{
+		uniqueID: uniqueID,
+		rcvr:     reflect.ValueOf(impl),
+		methods:  make(map[string]*serviceMethod),
+	}
+
+	typ := reflect.TypeOf(impl)
+	for i := 0; i < typ.NumMethod(); i++ {
+		sm, ok := newServiceMethod(typ.Method(i))
+		if !ok {
+			continue
+		}
+		s.methods[sm.method.Name] = sm
+		s.methods[sm.javaname] = sm
+	}
+
+	if len(s.methods) == 0 {
+		return ErrServiceNoMethods
+	}
+
+	srv.services.Lock()
+	defer srv.services.Unlock()
+
+	if _, ok := srv.services.services[uniqueID]; ok {
+		return ErrServiceRegistered
+	}
+
+	if srv.services.services == nil {
+		srv.services.services = make(map[string]*service, 8)
+	}
+	srv.services.services[uniqueID] = s
+
+	return nil
+}
+
+func newServiceMethod(m reflect.Method) (*serviceMethod, bool) {
+	if m.PkgPath != "" { // unexported
+		return nil, false
+	}
+
+	sm := &serviceMethod{method: m}
+
+	mtype := m.Type
+	for i := 1; i < mtype.NumIn(); i++ { // skip the receiver
+		in := mtype.In(i)
+		if i == 1 && in == typeOfContext {
+			sm.withctx = true
+			continue
+		}
+		sm.args = append(sm.args, in)
+	}
+
+	switch mtype.NumOut() {
+	case 0:
+	case 1:
+		if mtype.Out(0) == typeOfError {
+			sm.witherr = true
+		} else {
+			sm.withres = true
+		}
+	case 2:
+		if mtype.Out(1) != typeOfError {
+			return nil, false
+		}
+		sm.withres = true
+		sm.witherr = true
+	default:
+		return nil, false
+	}
+
+	r, n := utf8.DecodeRuneInString(m.Name)
+	sm.javaname = string(unicode.ToLower(r)) + m.Name[n:]
+
+	return sm, true
+}
+
+// HandleProto registers fn to serve the protobuf method of service, see ProtoServeMux.
+func (srv *Server) HandleProto(service, method string, req proto.Message, fn ProtoMethodFunc) {
+	srv.protos.HandleProto(service, method, req, fn)
+}
+
+func (srv *Server) serveSofaBOLT(rw ResponseWriter, req *Request) {
+	if s := srv.lookupService(req); s != nil {
+		s.serve(rw, req)
+		return
+	}
+
+	if pm := srv.protos.lookup(req); pm != nil {
+		pm.serve(rw, req)
+		return
+	}
+
+	if srv.handler != nil {
+		srv.handler.ServeSofaBOLT(rw, req)
+		return
+	}
+
+	rw.GetResponse().SetStatus(StatusNoProcessor)
+	// nolint
+	rw.Write()
+}
+
+func (srv *Server) lookupService(req *Request) *service {
+	if string(req.GetClass()) != ClassRequest {
+		return nil
+	}
+
+	srv.services.RLock()
+	defer srv.services.RUnlock()
+
+	if len(srv.services.services) == 0 {
+		return nil
+	}
+
+	return srv.services.services[req.GetRPCHeaders().GetTargetService()]
+}
+
+func (s *service) serve(rw ResponseWriter, req *Request) {
+	res := rw.GetResponse()
+
+	sm, ok := s.methods[req.GetRPCHeaders().GetMethodName()]
+	if !ok {
+		res.SetStatus(StatusNoProcessor)
+		// nolint
+		rw.Write()
+		return
+	}
+
+	args, err := sm.decodeArgs(req)
+	if err != nil {
+		res.SetStatus(StatusServerDeseralException)
+		// nolint
+		rw.Write()
+		return
+	}
+
+	in := make([]reflect.Value, 0, len(sm.args)+2)
+	in = append(in, s.rcvr)
+	if sm.withctx {
+		in = append(in, reflect.ValueOf(req.GetContext()))
+	}
+	in = append(in, args...)
+
+	out, err := s.call(sm, in)
+	if isSofaHessian(req.GetCodec(), req.GetClass()) {
+		err = s.writeHessian(res, sm, out, err)
+	} else {
+		err = s.writeBody(res, req.GetCodec(), sm, out, err)
+	}
+	if err != nil {
+		res.SetStatus(StatusServerSerialException)
+	}
+	// nolint
+	rw.Write()
+}
+
+// decodeArgs decodes the args from the hessian SofaRequest, or by the serializer
+// of the codec otherwise.
+func (sm *serviceMethod) decodeArgs(req *Request) ([]reflect.Value, error) {
+	args := make([]reflect.Value, 0, len(sm.args))
+
+	if isSofaHessian(req.GetCodec(), req.GetClass()) {
+		var sr SofaRequest
+		if err := req.GetSofaRequest(&sr); err != nil {
+			return nil, err
+		}
+		if len(sr.Args) != len(sm.args) {
+			return nil, ErrServiceArgsMismatch
+		}
+		for i := range sm.args {
+			arg, err := convertArg(sr.Args[i], sm.args[i])
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		return args, nil
+	}
+
+	s, ok := GetSerializer(req.GetCodec())
+	if !ok {
+		return nil, ErrSerializerNotFound
+	}
+
+	ptrs := make([]interface{}, len(sm.args))
+	for i, typ := range sm.args {
+		if typ.Kind() == reflect.Ptr { // decode into a new value directly, e.g. proto.Message.
+			arg := reflect.New(typ.Elem())
+			ptrs[i] = arg.Interface()
+			args = append(args, arg)
+			continue
+		}
+		arg := reflect.New(typ)
+		ptrs[i] = arg.Interface()
+		args = append(args, arg.Elem())
+	}
+
+	if err := unmarshalArgs(s, req.GetContent(), ptrs); err != nil {
+		return nil, err
+	}
+
+	return args, nil
+}
+
+func (s *service) writeHessian(res *Response, sm *serviceMethod, out []reflect.Value, err error) error {
+	var sres SofaResponse
+	if err != nil {
+		sres.IsError = true
+		sres.ErrorMsg = err.Error()
+
+	} else if sm.witherr && !out[len(out)-1].IsNil() {
+		sres.AppResponse = s.exception(sm, out[len(out)-1].Interface().(error))
+
+	} else if sm.withres && !isNilValue(out[0]) {
+		sres.AppResponse = out[0].Interface()
+	}
+
+	return res.SetSofaResponse(&sres)
+}
+
+// writeBody writes the result by the serializer of the codec. Errors are flagged
+// by the header sofa_head_response_error with the message as the content.
+func (s *service) writeBody(res *Response, codec Codec, sm *serviceMethod, out []reflect.Value, err error) error {
+	if err == nil && sm.witherr && !out[len(out)-1].IsNil() {
+		err = out[len(out)-1].Interface().(error)
+	}
+
+	res.SetCodec(codec).SetClassString(ClassResponse)
+	if codec == CodecJSON {
+		res.GetHeaders().Set("content-type", ContentTypeJSON)
+	}
+
+	if err != nil {
+		res.SetContentString(err.Error())
+		res.GetHeaders().Set(headerResponseError, "true")
+		return nil
+	}
+
+	if !sm.withres || isNilValue(out[0]) {
+		return nil
+	}
+
+	return res.SetBody(out[0].Interface())
+}
+
+func (s *service) call(sm *serviceMethod, in []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sofabolt: %s.%s panic: %v", s.uniqueID, sm.javaname, r)
+		}
+	}()
+	return sm.method.Func.Call(in), nil
+}
+
+func (s *service) exception(sm *serviceMethod, err error) *javaobject.SofaRPCServerException {
+	return &javaobject.SofaRPCServerException{
+		DetailMessage: err.Error(),
+		StackTrace: javaobject.JavaLangStackTraceElements{{
+			DeclaringClass: s.uniqueID,
+			MethodName:     sm.javaname,
+			LineNumber:     -1,
+		}},
+	}
+}
+
+// convertArg converts the hessian decoded v to the type t.
+func convertArg(v interface{}, t reflect.Type) (reflect.Value, error) {
+	if v == nil {
+		return reflect.Zero(t), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+
+	switch {
+	case rt.AssignableTo(t):
+		return rv, nil
+
+	case rt.Kind() == reflect.Ptr && rt.Elem().AssignableTo(t):
+		return rv.Elem(), nil
+
+	case t.Kind() == reflect.Ptr && rt.AssignableTo(t.Elem()):
+		p := reflect.New(t.Elem())
+		p.Elem().Set(rv)
+		return p, nil
+
+	case isNumberKind(rt.Kind()) && isNumberKind(t.Kind()):
+		return rv.Convert(t), nil
+
+	case rt.Kind() == reflect.Slice && t.Kind() == reflect.Slice:
+		s := reflect.MakeSlice(t, rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			e, err := convertArg(rv.Index(i).Interface(), t.Elem())
+			if err != nil {
+				return s, err
+			}
+			s.Index(i).Set(e)
+		}
+		return s, nil
+
+	case rt.Kind() == reflect.Map && t.Kind() == reflect.Map:
+		m := reflect.MakeMapWithSize(t, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k, err := convertArg(iter.Key().Interface(), t.Key())
+			if err != nil {
+				return m, err
+			}
+			e, err := convertArg(iter.Value().Interface(), t.Elem())
+			if err != nil {
+				return m, err
+			}
+			m.SetMapIndex(k, e)
+		}
+		return m, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("sofabolt: cannot convert %s to %s", rt, t)
+}
+
+// isSofaHessian reports whether the content is the hessian SofaRequest or SofaResponse.
+func isSofaHessian(codec Codec, class []byte) bool {
+	return codec == CodecHessian2 && (string(class) == ClassRequest || string(class) == ClassResponse)
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || k == reflect.Float32 || k == reflect.Float64
+}
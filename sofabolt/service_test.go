@@ -0,0 +1,133 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type userService struct{}
+
+func (s *userService) Echo(ctx context.Context, u *sofaTestUser) (*sofaTestUser, error) {
+	return u, nil
+}
+
+func (s *userService) Add(a, b int32) int32 { return a + b }
+
+func (s *userService) Join(names []string, sep string) string {
+	var r string
+	for i, n := range names {
+		if i > 0 {
+			r += sep
+		}
+		r += n
+	}
+	return r
+}
+
+func (s *userService) Fail() error { return errors.New("failed") }
+
+func (s *userService) Panic() { panic("boom") }
+
+func TestServerRegisterService(t *testing.T) {
+	p0, p1 := net.Pipe()
+	srv, err := NewServer(WithServerHandler(HandlerFunc(func(rw ResponseWriter, r *Request) {
+		rw.GetResponse().SetContentString("fallback")
+		rw.Write()
+	})))
+	require.Nil(t, err)
+	require.Nil(t, srv.RegisterService("com.alipay.test.UserService:1.0", &userService{}))
+	require.Equal(t, ErrServiceRegistered, srv.RegisterService("com.alipay.test.UserService:1.0", &userService{}))
+	require.Equal(t, ErrServiceNoMethods, srv.RegisterService("com.alipay.test.Empty:1.0", struct{}{}))
+
+	go func() {
+		srv.ServeConn(p1)
+	}()
+
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	v, err := c.Invoke(ctx, "com.alipay.test.UserService:1.0", "echo", &sofaTestUser{Name: "foo", Age: 1})
+	require.Nil(t, err)
+	require.Equal(t, &sofaTestUser{Name: "foo", Age: 1}, v)
+
+	v, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "add", int32(1), int32(2))
+	require.Nil(t, err)
+	require.EqualValues(t, 3, v)
+
+	v, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "Join", []string{"a", "b"}, ",")
+	require.Nil(t, err)
+	require.Equal(t, "a,b", v)
+
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "fail")
+	require.True(t, errors.Is(err, ErrRemoteServerException))
+	require.Equal(t, "failed", err.(*RemoteError).Message)
+	require.Equal(t, "com.alipay.remoting.rpc.exception.RpcServerException", err.(*RemoteError).Class)
+
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "panic")
+	require.Equal(t, &RemoteError{
+		Status:  StatusServerException,
+		Message: "sofabolt: com.alipay.test.UserService:1.0.panic panic: boom",
+	}, err)
+
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "unknown")
+	require.Equal(t, &RemoteError{Status: StatusNoProcessor}, err)
+
+	_, err = c.Invoke(ctx, "com.alipay.test.UserService:1.0", "add", "a", "b")
+	require.Equal(t, &RemoteError{Status: StatusServerDeseralException}, err)
+
+	req := AcquireRequest()
+	res := AcquireResponse()
+	require.Nil(t, req.SetSofaRequest(&SofaRequest{
+		TargetServiceUniqueName: "com.alipay.test.OtherService:1.0",
+		MethodName:              "echo",
+	}))
+	require.Nil(t, c.DoTimeout(req, res, time.Second))
+	require.Equal(t, "fallback", string(res.GetContent()))
+}
+
+func TestServerRegisterServiceWithoutHandler(t *testing.T) {
+	p0, p1 := net.Pipe()
+	srv, err := NewServer()
+	require.Nil(t, err)
+	require.Nil(t, srv.RegisterService("com.alipay.test.UserService:1.0", &userService{}))
+
+	go func() {
+		srv.ServeConn(p1)
+	}()
+
+	c, err := NewClient(WithClientConn(p0))
+	require.Nil(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	v, err := c.Invoke(ctx, "com.alipay.test.UserService:1.0", "add", int32(1), int32(2))
+	require.Nil(t, err)
+	require.EqualValues(t, 3, v)
+
+	_, err = c.Invoke(ctx, "com.alipay.test.OtherService:1.0", "echo")
+	require.Equal(t, &RemoteError{Status: StatusNoProcessor}, err)
+}
@@ -18,3 +18,6 @@ import (
 )
 
 type SimpleMap = fastsimplemap.FastSimpleMap
+
+// SimpleMapOptions configures the order and the decoding limits of the headers.
+type SimpleMapOptions = fastsimplemap.Options
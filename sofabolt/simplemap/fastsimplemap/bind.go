@@ -0,0 +1,452 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package fastsimplemap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const bindTag = "bolt"
+
+var (
+	ErrBindTarget      = errors.New("simplemap: bind target must be a non-nil pointer to struct")
+	ErrFromSource      = errors.New("simplemap: from source must be a struct or a non-nil pointer to struct")
+	ErrBindUnsupported = errors.New("simplemap: bind field type is not supported")
+	ErrBindRecursive   = errors.New("simplemap: bind struct is recursive")
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+type bindKind uint8
+
+const (
+	bindString bindKind = iota
+	bindBool
+	bindInt
+	bindUint
+	bindFloat
+	bindDuration
+	bindStruct
+	bindStructPtr
+)
+
+// bindField is a tagged field of a struct. The nested structs are flattened with
+// the key of the field as the prefix of their keys.
+type bindField struct {
+	index     int
+	key       []byte
+	kind      bindKind
+	bits      int
+	omitempty bool
+	nested    *bindPlan
+}
+
+type bindPlan struct {
+	fields []bindField
+}
+
+type bindPlanResult struct {
+	plan *bindPlan
+	err  error
+}
+
+var bindPlans sync.Map // reflect.Type -> bindPlanResult
+
+// Bind sets the fields of the struct v points to from the headers by the tags,
+// e.g. `bolt:"key"`. A struct field, or a pointer to struct which is allocated
+// once any of its keys exists, is flattened with its key as the prefix, e.g.
+// `bolt:"rpc_trace_context."`. The untagged fields except the embedded structs
+// are skipped, and the fields of the missing or empty headers are left
+// untouched. Strings, bools, numbers and time.Duration are supported and only
+// the strings allocate.
+func (m *FastSimpleMap) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrBindTarget
+	}
+
+	plan, err := bindPlanOf(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	return m.bind(plan, rv.Elem())
+}
+
+// From sets the headers from the fields of v by the tags as Bind does. The zero
+// fields with the option omitempty, e.g. `bolt:"key,omitempty"`, and the nil
+// pointers to struct are skipped.
+func (m *FastSimpleMap) From(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ErrFromSource
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ErrFromSource
+	}
+
+	plan, err := bindPlanOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	m.from(plan, rv)
+	return nil
+}
+
+func (m *FastSimpleMap) bind(plan *bindPlan, rv reflect.Value) error {
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		fv := rv.Field(f.index)
+
+		switch f.kind {
+		case bindStruct:
+			if err := m.bind(f.nested, fv); err != nil {
+				return err
+			}
+			continue
+
+		case bindStructPtr:
+			if fv.IsNil() {
+				if !m.hasAny(f.nested) {
+					continue
+				}
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			if err := m.bind(f.nested, fv.Elem()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b := m.get(f.key)
+		if len(b) == 0 {
+			continue
+		}
+
+		if err := bindValue(f, fv, b); err != nil {
+			return fmt.Errorf("simplemap: bind %s: %w", f.key, err)
+		}
+	}
+
+	return nil
+}
+
+func bindValue(f *bindField, fv reflect.Value, b []byte) error {
+	switch f.kind {
+	case bindString:
+		fv.SetString(string(b))
+
+	case bindBool:
+		x, err := strconv.ParseBool(b2s(b))
+		if err != nil {
+			return err
+		}
+		fv.SetBool(x)
+
+	case bindInt:
+		x, err := strconv.ParseInt(b2s(b), 10, f.bits)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(x)
+
+	case bindUint:
+		x, err := strconv.ParseUint(b2s(b), 10, f.bits)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(x)
+
+	case bindFloat:
+		x, err := strconv.ParseFloat(b2s(b), f.bits)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(x)
+
+	case bindDuration:
+		x, err := time.ParseDuration(b2s(b))
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(x))
+	}
+
+	return nil
+}
+
+func (m *FastSimpleMap) hasAny(plan *bindPlan) bool {
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		if f.nested != nil {
+			if m.hasAny(f.nested) {
+				return true
+			}
+			continue
+		}
+
+		if len(m.get(f.key)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *FastSimpleMap) from(plan *bindPlan, rv reflect.Value) {
+	var buf [64]byte
+
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		fv := rv.Field(f.index)
+
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+
+		switch f.kind {
+		case bindStruct:
+			m.from(f.nested, fv)
+
+		case bindStructPtr:
+			if !fv.IsNil() {
+				m.from(f.nested, fv.Elem())
+			}
+
+		case bindString:
+			m.set(f.key, s2b(fv.String()))
+
+		case bindBool:
+			m.set(f.key, strconv.AppendBool(buf[:0], fv.Bool()))
+
+		case bindInt:
+			m.set(f.key, strconv.AppendInt(buf[:0], fv.Int(), 10))
+
+		case bindUint:
+			m.set(f.key, strconv.AppendUint(buf[:0], fv.Uint(), 10))
+
+		case bindFloat:
+			m.set(f.key, strconv.AppendFloat(buf[:0], fv.Float(), 'g', -1, f.bits))
+
+		case bindDuration:
+			m.set(f.key, appendDuration(buf[:0], time.Duration(fv.Int())))
+		}
+	}
+}
+
+func bindPlanOf(t reflect.Type) (*bindPlan, error) {
+	if r, ok := bindPlans.Load(t); ok {
+		res := r.(bindPlanResult)
+		return res.plan, res.err
+	}
+
+	plan, err := newBindPlan(t, "", make(map[reflect.Type]bool))
+	bindPlans.Store(t, bindPlanResult{plan: plan, err: err})
+
+	return plan, err
+}
+
+func newBindPlan(t reflect.Type, prefix string, visiting map[reflect.Type]bool) (*bindPlan, error) {
+	if visiting[t] {
+		return nil, ErrBindRecursive
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	plan := &bindPlan{}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag, ok := sf.Tag.Lookup(bindTag)
+		if tag == "-" {
+			continue
+		}
+		if !ok && !sf.Anonymous { // only the embedded structs are flattened without tags
+			continue
+		}
+		if sf.PkgPath != "" && (!sf.Anonymous || sf.Type.Kind() != reflect.Struct) { // unexported
+			continue
+		}
+
+		key, opts := tag, ""
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			key, opts = tag[:j], tag[j+1:]
+		}
+
+		f := bindField{
+			index:     i,
+			key:       []byte(prefix + key),
+			omitempty: opts == "omitempty",
+		}
+
+		ft := sf.Type
+		switch {
+		case ft == durationType:
+			f.kind = bindDuration
+		case ft.Kind() == reflect.String:
+			f.kind = bindString
+		case ft.Kind() == reflect.Bool:
+			f.kind = bindBool
+		case ft.Kind() >= reflect.Int && ft.Kind() <= reflect.Int64:
+			f.kind, f.bits = bindInt, ft.Bits()
+		case ft.Kind() >= reflect.Uint && ft.Kind() <= reflect.Uint64:
+			f.kind, f.bits = bindUint, ft.Bits()
+		case ft.Kind() == reflect.Float32 || ft.Kind() == reflect.Float64:
+			f.kind, f.bits = bindFloat, ft.Bits()
+		case ft.Kind() == reflect.Struct:
+			f.kind = bindStruct
+		case ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct:
+			f.kind = bindStructPtr
+			ft = ft.Elem()
+		default:
+			if !ok { // untagged embedded fields of other types
+				continue
+			}
+			return nil, fmt.Errorf("%w: %s %s", ErrBindUnsupported, sf.Name, sf.Type)
+		}
+
+		if f.kind == bindStruct || f.kind == bindStructPtr {
+			nested, err := newBindPlan(ft, string(f.key), visiting)
+			if err != nil {
+				return nil, err
+			}
+			f.key = nil
+			f.nested = nested
+		} else if !ok {
+			continue
+		} else if key == "" {
+			f.key = []byte(prefix + sf.Name)
+		}
+
+		plan.fields = append(plan.fields, f)
+	}
+
+	return plan, nil
+}
+
+// appendDuration appends d formatted as time.Duration.String does.
+func appendDuration(b []byte, d time.Duration) []byte {
+	var buf [32]byte
+	w := len(buf)
+
+	u := uint64(d)
+	neg := d < 0
+	if neg {
+		u = -u
+	}
+
+	if u < uint64(time.Second) {
+		// less than one second uses a smaller unit, e.g. "1.2ms"
+		var prec int
+		w--
+		buf[w] = 's'
+		w--
+		switch {
+		case u == 0:
+			return append(b, '0', 's')
+		case u < uint64(time.Microsecond):
+			prec = 0
+			buf[w] = 'n'
+		case u < uint64(time.Millisecond):
+			prec = 3
+			// U+00B5 'µ' micro sign == 0xC2 0xB5
+			w--
+			copy(buf[w:], "µ")
+		default:
+			prec = 6
+			buf[w] = 'm'
+		}
+		w, u = fmtFrac(buf[:w], u, prec)
+		w = fmtInt(buf[:w], u)
+	} else {
+		w--
+		buf[w] = 's'
+
+		w, u = fmtFrac(buf[:w], u, 9)
+
+		// u is now integer seconds
+		w = fmtInt(buf[:w], u%60)
+		u /= 60
+
+		// u is now integer minutes
+		if u > 0 {
+			w--
+			buf[w] = 'm'
+			w = fmtInt(buf[:w], u%60)
+			u /= 60
+
+			// u is now integer hours
+			if u > 0 {
+				w--
+				buf[w] = 'h'
+				w = fmtInt(buf[:w], u)
+			}
+		}
+	}
+
+	if neg {
+		w--
+		buf[w] = '-'
+	}
+
+	return append(b, buf[w:]...)
+}
+
+// fmtFrac formats the fraction of v/10**prec, e.g. ".12345", into the tail of
+// buf omitting the trailing zeros, and returns the start and v/10**prec.
+func fmtFrac(buf []byte, v uint64, prec int) (int, uint64) {
+	w := len(buf)
+	print := false
+	for i := 0; i < prec; i++ {
+		digit := v % 10
+		print = print || digit != 0
+		if print {
+			w--
+			buf[w] = byte(digit) + '0'
+		}
+		v /= 10
+	}
+	if print {
+		w--
+		buf[w] = '.'
+	}
+	return w, v
+}
+
+// fmtInt formats v into the tail of buf and returns the start.
+func fmtInt(buf []byte, v uint64) int {
+	w := len(buf)
+	if v == 0 {
+		w--
+		buf[w] = '0'
+	} else {
+		for v > 0 {
+			w--
+			buf[w] = byte(v%10) + '0'
+			v /= 10
+		}
+	}
+	return w
+}
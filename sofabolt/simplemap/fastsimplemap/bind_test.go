@@ -0,0 +1,237 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package fastsimplemap
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type bindTrace struct {
+	TraceID string `bolt:"sofaTraceId"`
+	RPCID   string `bolt:"sofaRpcId"`
+	Sampled bool   `bolt:"samp,omitempty"`
+}
+
+type bindCaller struct {
+	App  string `bolt:"app"`
+	Zone string `bolt:"zone,omitempty"`
+}
+
+type bindCommon struct {
+	Protocol string `bolt:"protocol"`
+}
+
+type bindHeaders struct {
+	bindCommon
+	Service  string        `bolt:"service"`
+	Method   string        `bolt:"sofa_head_method_name"`
+	Retries  int8          `bolt:"retries"`
+	Size     uint32        `bolt:"size"`
+	Weight   float64       `bolt:"weight"`
+	OneWay   bool          `bolt:"oneway"`
+	Timeout  time.Duration `bolt:"timeout"`
+	Ignored  string        `bolt:"-"`
+	Untagged string
+	Trace    bindTrace   `bolt:"rpc_trace_context."`
+	Caller   *bindCaller `bolt:"caller."`
+	internal string      `bolt:"internal"` // nolint
+}
+
+func TestFastSimpleMapFromBind(t *testing.T) {
+	src := bindHeaders{
+		bindCommon: bindCommon{Protocol: "bolt"},
+		Service:    "com.alipay.test.UserService:1.0",
+		Method:     "echo",
+		Retries:    -3,
+		Size:       1024,
+		Weight:     0.5,
+		OneWay:     true,
+		Timeout:    1500 * time.Millisecond,
+		Ignored:    "ignored",
+		Untagged:   "untagged",
+		Trace:      bindTrace{TraceID: "0a0fe8f1157000000000110014567", RPCID: "0.1"},
+		Caller:     &bindCaller{App: "caller"},
+	}
+
+	var sm FastSimpleMap
+	if err := sm.From(&src); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range map[string]string{
+		"protocol":                      "bolt",
+		"service":                       "com.alipay.test.UserService:1.0",
+		"sofa_head_method_name":         "echo",
+		"retries":                       "-3",
+		"size":                          "1024",
+		"weight":                        "0.5",
+		"oneway":                        "true",
+		"timeout":                       "1.5s",
+		"rpc_trace_context.sofaTraceId": "0a0fe8f1157000000000110014567",
+		"rpc_trace_context.sofaRpcId":   "0.1",
+		"rpc_trace_context.samp":        "",
+		"caller.app":                    "caller",
+		"caller.zone":                   "",
+		"Ignored":                       "",
+		"Untagged":                      "",
+		"internal":                      "",
+	} {
+		if got := sm.Get(k); got != v {
+			t.Fatalf("expect %s=%q but got %q", k, v, got)
+		}
+	}
+
+	var dst bindHeaders
+	dst.Untagged = "keep"
+	if err := sm.Bind(&dst); err != nil {
+		t.Fatal(err)
+	}
+	src.Ignored = ""
+	src.Untagged = "keep"
+	if dst.Caller == nil || *dst.Caller != *src.Caller {
+		t.Fatalf("expect caller %+v but got %+v", src.Caller, dst.Caller)
+	}
+	dst.Caller = src.Caller
+	if dst != src {
+		t.Fatalf("expect %+v but got %+v", src, dst)
+	}
+
+	// the nested pointer is allocated only if any of its keys exists
+	sm.Del("caller.app")
+	dst = bindHeaders{}
+	if err := sm.Bind(&dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Caller != nil {
+		t.Fatalf("expect nil caller but got %+v", dst.Caller)
+	}
+
+	sm.Set("size", "-1")
+	if err := sm.Bind(&dst); err == nil {
+		t.Fatal("expect parse error")
+	}
+	sm.Set("size", "1")
+	sm.Set("timeout", "1x")
+	if err := sm.Bind(&dst); err == nil {
+		t.Fatal("expect parse error")
+	}
+}
+
+func TestFastSimpleMapBindErrors(t *testing.T) {
+	var sm FastSimpleMap
+
+	var h bindHeaders
+	if err := sm.Bind(h); err != ErrBindTarget {
+		t.Fatalf("expect ErrBindTarget but got %v", err)
+	}
+	if err := sm.Bind((*bindHeaders)(nil)); err != ErrBindTarget {
+		t.Fatalf("expect ErrBindTarget but got %v", err)
+	}
+	if err := sm.From("string"); err != ErrFromSource {
+		t.Fatalf("expect ErrFromSource but got %v", err)
+	}
+	if err := sm.From(h); err != nil {
+		t.Fatal(err)
+	}
+
+	type unsupported struct {
+		Values []string `bolt:"values"`
+	}
+	if err := sm.Bind(&unsupported{}); !errors.Is(err, ErrBindUnsupported) {
+		t.Fatalf("expect ErrBindUnsupported but got %v", err)
+	}
+
+	type recursive struct {
+		Next *recursive `bolt:"next."`
+	}
+	if err := sm.From(&recursive{}); err != ErrBindRecursive {
+		t.Fatalf("expect ErrBindRecursive but got %v", err)
+	}
+}
+
+func TestFastSimpleMapFromBindAllocs(t *testing.T) {
+	type numbers struct {
+		Service string        `bolt:"service"`
+		Count   int           `bolt:"count"`
+		Ratio   float64       `bolt:"ratio"`
+		OK      bool          `bolt:"ok"`
+		Trace   bindTrace     `bolt:"rpc_trace_context."`
+		Timeout time.Duration `bolt:"timeout,omitempty"`
+	}
+
+	var sm FastSimpleMap
+	src := &numbers{Service: "service", Count: 42, Ratio: 0.25, OK: true, Trace: bindTrace{TraceID: "trace"},
+		Timeout: 1500 * time.Millisecond}
+	var dst numbers
+	if err := sm.From(src); err != nil { // warm up the plan and the map
+		t.Fatal(err)
+	}
+
+	if n := testing.AllocsPerRun(100, func() {
+		// nolint
+		sm.From(src)
+	}); n != 0 {
+		t.Fatalf("expect From without allocations but got %v", n)
+	}
+
+	// only the strings allocate
+	sm.Del("service")
+	sm.Del("rpc_trace_context.sofaTraceId")
+	if n := testing.AllocsPerRun(100, func() {
+		// nolint
+		sm.Bind(&dst)
+	}); n != 0 {
+		t.Fatalf("expect Bind without allocations but got %v", n)
+	}
+	if dst.Count != 42 || dst.Ratio != 0.25 || !dst.OK || dst.Timeout != src.Timeout {
+		t.Fatalf("unexpected %+v", dst)
+	}
+}
+
+func TestAppendDuration(t *testing.T) {
+	for _, d := range []time.Duration{
+		0, 1, 999, time.Microsecond, 1500 * time.Nanosecond, time.Millisecond, 1100 * time.Microsecond,
+		time.Second, 1500 * time.Millisecond, 90 * time.Minute, 2*time.Hour + 3*time.Minute + 4500*time.Millisecond,
+		-1, -time.Millisecond, -90 * time.Second, math.MaxInt64, math.MinInt64,
+	} {
+		if got := string(appendDuration([]byte("x"), d)); got != "x"+d.String() {
+			t.Fatalf("expect x%s but got %s", d, got)
+		}
+	}
+}
+
+func BenchmarkFastSimpleMapFrom(b *testing.B) {
+	var sm FastSimpleMap
+	src := &bindHeaders{Service: "service", Method: "echo", Retries: 1, Trace: bindTrace{TraceID: "trace", RPCID: "0.1"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// nolint
+		sm.From(src)
+	}
+}
+
+func BenchmarkFastSimpleMapBind(b *testing.B) {
+	var sm FastSimpleMap
+	// nolint
+	sm.From(&bindHeaders{Retries: 1, Size: 2, Weight: 0.5, OneWay: true, Timeout: time.Second})
+	var dst bindHeaders
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// nolint
+		sm.Bind(&dst)
+	}
+}
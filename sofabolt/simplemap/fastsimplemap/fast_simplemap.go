@@ -24,8 +24,11 @@ import (
 )
 
 var (
-	ErrSimleMapKeyFailed   = errors.New("simplemap: parse key failed")
-	ErrSimleMapValueFailed = errors.New("simplemap: parse value failed")
+	ErrSimleMapKeyFailed       = errors.New("simplemap: parse key failed")
+	ErrSimleMapValueFailed     = errors.New("simplemap: parse value failed")
+	ErrSimpleMapTooManyEntries = errors.New("simplemap: too many entries")
+	ErrSimpleMapKeyTooLarge    = errors.New("simplemap: key too large")
+	ErrSimpleMapValueTooLarge  = errors.New("simplemap: value too large")
 )
 
 var (
@@ -45,14 +48,22 @@ type kvHeader struct {
 
 	key   []byte
 	value []byte
+	// null is the value decoded from the null length, which is kept by the ordered
+	// map to encode the same bytes.
+	null bool
 }
 
 func (k *kvHeader) Reset() {
 	k.key = k.key[:0]
 	k.value = k.value[:0]
+	k.null = false
 }
 
 func (k *kvHeader) Equal(dst *kvHeader) bool {
+	if k.null != dst.null {
+		return false
+	}
+
 	if !bytes.Equal(k.key, dst.key) {
 		return false
 	}
@@ -64,6 +75,20 @@ func (k *kvHeader) Equal(dst *kvHeader) bool {
 	return true
 }
 
+// Options configures a FastSimpleMap. The zero value keeps the last value of the
+// duplicate keys and decodes without limits.
+type Options struct {
+	// Ordered preserves the order and the duplicate keys of the entries, so the
+	// decoded map encodes to the same bytes.
+	Ordered bool
+	// MaxEntries limits the number of the decoded entries.
+	MaxEntries int
+	// MaxKeySize limits the length of the decoded keys.
+	MaxKeySize int
+	// MaxValueSize limits the length of the decoded values.
+	MaxValueSize int
+}
+
 type FastSimpleMap struct {
 	// nolint
 	noCopy noCopy
@@ -74,13 +99,28 @@ type FastSimpleMap struct {
 	contentType   []byte
 	kvs           []kvHeader
 
-	raw []byte
+	raw  []byte
+	opts Options
 }
 
 func New() FastSimpleMap {
 	return FastSimpleMap{}
 }
 
+// NewWithOptions returns a map configured by o.
+func NewWithOptions(o Options) FastSimpleMap {
+	return FastSimpleMap{opts: o}
+}
+
+// SetOptions resets the map and configures it by o. The options are kept by Reset.
+func (m *FastSimpleMap) SetOptions(o Options) *FastSimpleMap {
+	m.Reset()
+	m.opts = o
+	return m
+}
+
+func (m *FastSimpleMap) GetOptions() Options { return m.opts }
+
 func (m *FastSimpleMap) MarshalLogObject(enc sofalogger.ObjectEncoder) error {
 	m.Range(func(k, v string) {
 		enc.AddString(k, v)
@@ -123,6 +163,7 @@ func (m *FastSimpleMap) GetEncodeSize() int {
 }
 
 func (m *FastSimpleMap) CopyTo(dst *FastSimpleMap) {
+	dst.opts = m.opts
 	dst.host = append(dst.host[:0], m.host...)
 	dst.service = append(dst.service[:0], m.service...)
 	dst.contentType = append(dst.contentType[:0], m.contentType...)
@@ -140,6 +181,7 @@ func (m *FastSimpleMap) CopyTo(dst *FastSimpleMap) {
 	for i := 0; i < n; i++ {
 		dst.kvs[i].key = append(dst.kvs[i].key[:0], m.kvs[i].key...)
 		dst.kvs[i].value = append(dst.kvs[i].value[:0], m.kvs[i].value...)
+		dst.kvs[i].null = m.kvs[i].null
 	}
 }
 
@@ -163,7 +205,12 @@ func (m *FastSimpleMap) Encode(data []byte) int {
 	}
 
 	for i := 0; i < len(m.kvs); i++ {
-		b.MustPutUint32String(b2s(m.kvs[i].key)).MustPutUint32String(b2s(m.kvs[i].value))
+		b.MustPutUint32String(b2s(m.kvs[i].key))
+		if m.kvs[i].null {
+			b.MustPutUint32(math.MaxUint32)
+		} else {
+			b.MustPutUint32String(b2s(m.kvs[i].value))
+		}
 	}
 
 	return b.Pos()
@@ -205,18 +252,28 @@ func (m *FastSimpleMap) Decode(data []byte) error {
 	l := len(m.raw)
 
 	var (
-		k   string
-		v   string
-		d   []byte
-		err error
+		k       string
+		v       string
+		d       []byte
+		err     error
+		entries int
 	)
 
 	for l != 0 {
+		entries++
+		if m.opts.MaxEntries > 0 && entries > m.opts.MaxEntries {
+			return ErrSimpleMapTooManyEntries
+		}
+
 		k, err = b.Uint32String()
 		if err != nil {
 			return ErrSimleMapKeyFailed
 		}
 
+		if m.opts.MaxKeySize > 0 && len(k) > m.opts.MaxKeySize {
+			return ErrSimpleMapKeyTooLarge
+		}
+
 		l -= 4
 		l -= len(k)
 
@@ -225,11 +282,16 @@ func (m *FastSimpleMap) Decode(data []byte) error {
 			return ErrSimleMapValueFailed
 		}
 
-		if u32 == math.MaxUint32 { // Real Null
+		null := u32 == math.MaxUint32
+		if null { // Real Null
 			v = ""
 		} else if u32 == 0 {
 			v = ""
 		} else {
+			if m.opts.MaxValueSize > 0 && uint64(u32) > uint64(m.opts.MaxValueSize) {
+				return ErrSimpleMapValueTooLarge
+			}
+
 			if b.Check(int(u32)) == false {
 				return ErrSimleMapValueFailed
 			}
@@ -241,13 +303,22 @@ func (m *FastSimpleMap) Decode(data []byte) error {
 		l -= 4
 		l -= len(v)
 
-		m.set(s2b(k), s2b(v))
+		if m.opts.Ordered {
+			m.add(s2b(k), s2b(v)).null = null
+		} else {
+			m.set(s2b(k), s2b(v))
+		}
 	}
 
 	return nil
 }
 
 func (m *FastSimpleMap) set(k, v []byte) {
+	if m.opts.Ordered {
+		m.setOrdered(k, v)
+		return
+	}
+
 	switch b2s(k) {
 	case StrHost:
 		m.setHost(v)
@@ -273,14 +344,39 @@ func (m *FastSimpleMap) set(k, v []byte) {
 			}
 		}
 
-		if cap(m.kvs) <= n {
-			m.kvs = append(m.kvs, make([]kvHeader, 4)...)
+		m.add(k, v)
+	}
+}
+
+// setOrdered sets the value of the first entry of k and deletes the others.
+func (m *FastSimpleMap) setOrdered(k, v []byte) {
+	for i := 0; i < len(m.kvs); i++ {
+		kv := &m.kvs[i]
+		if b2s(kv.key) == b2s(k) {
+			kv.value = append(kv.value[:0], v...)
+			kv.null = false
+			m.delFrom(i+1, k)
+			return
 		}
-		m.kvs = m.kvs[:n+1]
+	}
+
+	m.add(k, v)
+}
 
-		m.kvs[n].key = append(m.kvs[n].key[:0], k...)
-		m.kvs[n].value = append(m.kvs[n].value[:0], v...)
+// add appends an entry whose buffers are reused.
+func (m *FastSimpleMap) add(k, v []byte) *kvHeader {
+	n := len(m.kvs)
+	if cap(m.kvs) <= n {
+		m.kvs = append(m.kvs, make([]kvHeader, 4)...)
 	}
+	m.kvs = m.kvs[:n+1]
+
+	kv := &m.kvs[n]
+	kv.key = append(kv.key[:0], k...)
+	kv.value = append(kv.value[:0], v...)
+	kv.null = false
+
+	return kv
 }
 
 func (m *FastSimpleMap) Get(k string) string {
@@ -292,6 +388,28 @@ func (m *FastSimpleMap) Set(k, v string) *FastSimpleMap {
 	return m
 }
 
+// Add adds the value of k. The ordered map keeps the existing values of k which
+// GetAll returns, otherwise Add is Set.
+func (m *FastSimpleMap) Add(k, v string) *FastSimpleMap {
+	if m.opts.Ordered {
+		m.add(s2b(k), s2b(v))
+	} else {
+		m.set(s2b(k), s2b(v))
+	}
+	return m
+}
+
+// GetAll returns the values of k in order, or nil if k does not exist.
+func (m *FastSimpleMap) GetAll(k string) []string {
+	var values []string
+	m.Range(func(key, value string) {
+		if key == k {
+			values = append(values, value)
+		}
+	})
+	return values
+}
+
 func (m *FastSimpleMap) Del(k string) {
 	m.del(s2b(k))
 }
@@ -319,29 +437,49 @@ func (m *FastSimpleMap) Range(fn func(k, v string)) {
 }
 
 func (m *FastSimpleMap) del(k []byte) {
-	switch b2s(k) {
-	case StrHost:
-		m.host = m.host[:0]
-		return
-	case StrContentType:
-		m.contentType = m.contentType[:0]
-		return
-	case StrService:
-		m.service = m.service[:0]
-		return
-	case StrAuthorization:
-		m.authorization = m.authorization[:0]
-		return
+	if !m.opts.Ordered {
+		switch b2s(k) {
+		case StrHost:
+			m.host = m.host[:0]
+			return
+		case StrContentType:
+			m.contentType = m.contentType[:0]
+			return
+		case StrService:
+			m.service = m.service[:0]
+			return
+		case StrAuthorization:
+			m.authorization = m.authorization[:0]
+			return
+		}
 	}
 
-	for i := 0; i < len(m.kvs); i++ {
+	m.delFrom(0, k)
+}
+
+// delFrom deletes the entries of k from the ith entry in place. The buffers of the
+// deleted entries are moved to the tail to be reused.
+func (m *FastSimpleMap) delFrom(i int, k []byte) {
+	n := i
+	for ; i < len(m.kvs); i++ {
 		if bytes.Equal(m.kvs[i].key, k) {
-			m.kvs = append(m.kvs[:i], m.kvs[i+1:]...)
+			continue
 		}
+		if i != n {
+			m.kvs[n].key, m.kvs[i].key = m.kvs[i].key, m.kvs[n].key
+			m.kvs[n].value, m.kvs[i].value = m.kvs[i].value, m.kvs[n].value
+			m.kvs[n].null = m.kvs[i].null
+		}
+		n++
 	}
+	m.kvs = m.kvs[:n]
 }
 
 func (m *FastSimpleMap) get(k []byte) []byte {
+	if m.opts.Ordered {
+		return m.getKV(k)
+	}
+
 	switch b2s(k) {
 	case StrHost:
 		return m.getHost()
@@ -353,6 +491,10 @@ func (m *FastSimpleMap) get(k []byte) []byte {
 		return m.getAuthenticate()
 	}
 
+	return m.getKV(k)
+}
+
+func (m *FastSimpleMap) getKV(k []byte) []byte {
 	for i := 0; i < len(m.kvs); i++ {
 		if bytes.Equal(m.kvs[i].key, k) {
 			return m.kvs[i].value
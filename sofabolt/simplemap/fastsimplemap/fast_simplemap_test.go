@@ -14,7 +14,10 @@
 package fastsimplemap
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -90,3 +93,132 @@ func TestFastSimpleMapCopy(t *testing.T) {
 		}
 	}
 }
+
+func TestFastSimpleMapDelReuse(t *testing.T) {
+	var sm FastSimpleMap
+	sm.Set("a", "1").Set("b", "2").Set("c", "3")
+	sm.Del("a")
+	sm.Set("d", "4")
+
+	if got := sm.String(); got != "b:2&c:3&d:4&" {
+		t.Fatalf("expect b:2&c:3&d:4& but got %s", got)
+	}
+}
+
+func encodeEntries(kvs ...string) []byte {
+	var b []byte
+	for i, s := range kvs {
+		if i%2 == 1 && s == "<null>" {
+			b = append(b, 0xff, 0xff, 0xff, 0xff)
+			continue
+		}
+		var l [4]byte
+		binary.BigEndian.PutUint32(l[:], uint32(len(s)))
+		b = append(b, l[:]...)
+		b = append(b, s...)
+	}
+	return b
+}
+
+func TestFastSimpleMapOrdered(t *testing.T) {
+	data := encodeEntries(
+		"x-trace", "1",
+		"service", "com.alipay.test.UserService:1.0",
+		"x-trace", "2",
+		"empty", "",
+		"null", "<null>",
+		"x-trace", "3",
+	)
+
+	sm := NewWithOptions(Options{Ordered: true})
+	if err := sm.Decode(data); err != nil {
+		t.Fatal(err)
+	}
+
+	b := make([]byte, sm.GetEncodeSize())
+	if n := sm.Encode(b); !bytes.Equal(b[:n], data) {
+		t.Fatalf("expect %x but got %x", data, b[:n])
+	}
+
+	var cm FastSimpleMap
+	sm.CopyTo(&cm)
+	if !cm.Equal(&sm) || !cm.GetOptions().Ordered {
+		t.Fatal("expect equal")
+	}
+
+	if got := sm.Get("x-trace"); got != "1" {
+		t.Fatalf("expect the first value but got %s", got)
+	}
+	if got := sm.Get("service"); got != "com.alipay.test.UserService:1.0" {
+		t.Fatalf("expect service but got %s", got)
+	}
+	if got := sm.GetAll("x-trace"); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Fatalf("expect all values but got %v", got)
+	}
+	if got := sm.GetAll("missing"); got != nil {
+		t.Fatalf("expect nil but got %v", got)
+	}
+
+	sm.Add("x-trace", "4")
+	if got := sm.GetAll("x-trace"); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Fatalf("expect all values but got %v", got)
+	}
+
+	sm.Set("x-trace", "5")
+	if got := sm.String(); got != "x-trace:5&service:com.alipay.test.UserService:1.0&empty:&null:&" {
+		t.Fatalf("unexpected %s", got)
+	}
+
+	sm.Add("x-trace", "6").Del("x-trace")
+	if got := sm.GetAll("x-trace"); got != nil {
+		t.Fatalf("expect nil but got %v", got)
+	}
+
+	// Reset keeps the options
+	sm.Reset()
+	sm.Add("k", "1").Add("k", "2")
+	if got := sm.GetAll("k"); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Fatalf("expect all values but got %v", got)
+	}
+
+	// the unordered map keeps the last value
+	var um FastSimpleMap
+	if err := um.Decode(data); err != nil {
+		t.Fatal(err)
+	}
+	um.Add("service", "s")
+	if got := um.GetAll("x-trace"); !reflect.DeepEqual(got, []string{"3"}) {
+		t.Fatalf("expect the last value but got %v", got)
+	}
+	if got := um.GetAll("service"); !reflect.DeepEqual(got, []string{"s"}) {
+		t.Fatalf("expect the last value but got %v", got)
+	}
+}
+
+func TestFastSimpleMapDecodeLimits(t *testing.T) {
+	data := encodeEntries("a", "1", "bb", "22", "ccc", "333")
+
+	for _, tc := range []struct {
+		opts Options
+		err  error
+	}{
+		{Options{}, nil},
+		{Options{MaxEntries: 3, MaxKeySize: 3, MaxValueSize: 3}, nil},
+		{Options{MaxEntries: 2}, ErrSimpleMapTooManyEntries},
+		{Options{MaxKeySize: 2}, ErrSimpleMapKeyTooLarge},
+		{Options{MaxValueSize: 2, Ordered: true}, ErrSimpleMapValueTooLarge},
+	} {
+		var sm FastSimpleMap
+		sm.SetOptions(tc.opts)
+		if err := sm.Decode(data); err != tc.err {
+			t.Fatalf("%+v: expect %v but got %v", tc.opts, tc.err, err)
+		}
+	}
+
+	// the declared length is rejected before checking the data
+	huge := []byte{0, 0, 0, 1, 'k', 0x7f, 0xff, 0xff, 0xff}
+	sm := NewWithOptions(Options{MaxValueSize: 1024})
+	if err := sm.Decode(huge); err != ErrSimpleMapValueTooLarge {
+		t.Fatalf("expect ErrSimpleMapValueTooLarge but got %v", err)
+	}
+}
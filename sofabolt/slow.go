@@ -0,0 +1,59 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import "time"
+
+const (
+	SideServer = "server"
+	SideClient = "client"
+)
+
+// SlowOptions configures the slow request detection.
+type SlowOptions struct {
+	// Threshold is the total time above which a request is slow.
+	Threshold time.Duration
+	// MaxContent truncates the dumped content to MaxContent bytes if positive.
+	MaxContent int
+	// OnSlow is called with every slow request.
+	OnSlow func(sr *SlowRequest)
+}
+
+// SlowRequest breaks down the time spent by a slow request.
+//
+// On the server side Queue is the time from the request was read to the
+// handler was called, Handler is the time spent by the handler and Flush is
+// the time spent writing and flushing the response. The responses buffered by
+// the flush interval of WithServerTimeout are flushed later, which is not counted.
+//
+// On the client side Queue is the time from the request was invoked to it was
+// written, Flush is the time spent writing the request and Handler is the time
+// waiting the response after the request was written.
+type SlowRequest struct {
+	Side     string
+	Remote   string
+	Request  string
+	Response string
+	Queue    time.Duration
+	Handler  time.Duration
+	Flush    time.Duration
+	Total    time.Duration
+}
+
+func (o *SlowOptions) dump(cmd interface{ StringWithContentLimit(int) string }) string {
+	if o.MaxContent > 0 {
+		return cmd.StringWithContentLimit(o.MaxContent)
+	}
+	return cmd.StringWithContentLimit(-1)
+}
@@ -0,0 +1,102 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowRequest(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		slows   []*SlowRequest
+		events  []*SlowRequest
+		onslow  = func(sr *SlowRequest) { lock.Lock(); slows = append(slows, sr); lock.Unlock() }
+		getslow = func() ([]*SlowRequest, []*SlowRequest) {
+			lock.Lock()
+			defer lock.Unlock()
+			return append([]*SlowRequest(nil), slows...), append([]*SlowRequest(nil), events...)
+		}
+	)
+
+	srv, err := NewServer(
+		WithServerSlow(&SlowOptions{Threshold: 20 * time.Millisecond, MaxContent: 2, OnSlow: onslow}),
+		WithServerOnEventHandler(func(_ *Server, _ error, sec *ServerEventContext) {
+			if sec.GetType() == ServerSlowRequestEvent {
+				lock.Lock()
+				events = append(events, sec.GetSlowRequest())
+				lock.Unlock()
+			}
+		}),
+		WithServerHandler(HandlerFunc(func(rw ResponseWriter, req *Request) {
+			if req.GetHeaders().Get("slow") != "" {
+				time.Sleep(30 * time.Millisecond)
+			}
+			rw.GetResponse().SetContentString("Hello World")
+			rw.Write() // nolint
+		})))
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go srv.ServeConn(p1) // nolint
+
+	c, err := NewClient(
+		WithClientConn(p0),
+		WithClientSlow(&SlowOptions{Threshold: 20 * time.Millisecond, OnSlow: onslow}),
+	)
+	require.Nil(t, err)
+	defer c.Close()
+
+	for _, slow := range []bool{false, true, false} {
+		req := AcquireRequest()
+		if slow {
+			req.GetHeaders().Set("slow", "1")
+		}
+		req.SetContentString("ping")
+		res := AcquireResponse()
+		require.Nil(t, c.DoTimeout(req, res, time.Second))
+		ReleaseRequest(req)
+		ReleaseResponse(res)
+	}
+
+	require.Eventually(t, func() bool {
+		slows, events := getslow()
+		return len(slows) == 2 && len(events) == 1
+	}, time.Second, time.Millisecond)
+
+	slows, events = getslow()
+	sides := map[string]*SlowRequest{}
+	for _, sr := range slows {
+		sides[sr.Side] = sr
+	}
+
+	server := sides[SideServer]
+	require.NotNil(t, server)
+	require.Equal(t, server, events[0])
+	require.Equal(t, "pipe", server.Remote)
+	require.True(t, server.Handler >= 30*time.Millisecond)
+	require.True(t, server.Total >= server.Queue+server.Handler+server.Flush)
+	require.Contains(t, server.Request, "Content:7069...,")
+	require.Contains(t, server.Response, "Content:4865...,")
+
+	client := sides[SideClient]
+	require.NotNil(t, client)
+	require.True(t, client.Handler >= 30*time.Millisecond)
+	require.Contains(t, client.Request, "Content:"+"70696e67"+",")
+}
@@ -31,4 +31,10 @@ var (
 	ErrClientServerTimeout   = errors.New("sofabolt: clientserver do timeout")
 	ErrClientDisableRedial   = errors.New("sofabolt: disable redial")
 	ErrClientNilConnection   = errors.New("sofabolt: client connection is nil")
+
+	ErrRetrierNilClientGetter = errors.New("sofabolt: retrier client getter cannot be nil")
+	ErrRetrierNoClient        = errors.New("sofabolt: retrier has no available client")
+
+	ErrKeepAliverNilDialer = errors.New("sofabolt: keepaliver dialer cannot be nil")
+	ErrKeepAliverPoolFull  = errors.New("sofabolt: keepaliver pool is full")
 )
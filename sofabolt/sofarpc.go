@@ -0,0 +1,269 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/sofastack/sofa-hessian-go/javaobject"
+	"github.com/sofastack/sofa-hessian-go/sofahessian"
+)
+
+var (
+	ErrSofaRequestMismatchClass  = errors.New("sofabolt: request class is not " + ClassRequest)
+	ErrSofaResponseMismatchClass = errors.New("sofabolt: response class is not " + ClassResponse)
+	ErrSofaMismatchCodec         = errors.New("sofabolt: codec is not hessian2")
+	ErrSofaArgSigsMismatch       = errors.New("sofabolt: length of args and arg sigs mismatch")
+)
+
+const (
+	headerTargetService = "sofa_head_target_service"
+	headerTargetApp     = "sofa_head_target_app"
+	headerMethodName    = "sofa_head_method_name"
+	headerResponseError = "sofa_head_response_error"
+)
+
+var sofaregistry sofahessian.ClassRegistry
+
+// nolint
+func init() {
+	sofaregistry.RegisterJavaClass(&javaobject.SofaRPCRequest{})
+	sofaregistry.RegisterJavaClass(&javaobject.SofaRPCResponse{})
+	sofaregistry.RegisterJavaClass(&javaobject.SofaRPCServerException{})
+}
+
+// RegisterSofaClass registers the java class so that args and app responses
+// of that class are decoded to v's type rather than a generic map.
+// It should be called in init before any request is served.
+func RegisterSofaClass(v sofahessian.JavaClassNameGetter) error {
+	_, err := sofaregistry.RegisterJavaClass(v)
+	return err
+}
+
+// SofaRequest is the body of a SOFARPC request which is encoded as
+// com.alipay.sofa.rpc.core.request.SofaRequest followed by the args.
+type SofaRequest struct {
+	TargetAppName           string
+	TargetServiceUniqueName string
+	MethodName              string
+	// MethodArgSigs is the java types of args. It's inferred from args if empty.
+	MethodArgSigs []string
+	Args          []interface{}
+	RequestProps  map[string]interface{}
+}
+
+func (s *SofaRequest) Reset() {
+	s.TargetAppName = ""
+	s.TargetServiceUniqueName = ""
+	s.MethodName = ""
+	s.MethodArgSigs = s.MethodArgSigs[:0]
+	s.Args = s.Args[:0]
+	s.RequestProps = nil
+}
+
+// SofaResponse is the body of a SOFARPC response.
+type SofaResponse struct {
+	IsError       bool
+	ErrorMsg      string
+	AppResponse   interface{}
+	ResponseProps map[string]string
+}
+
+func (s *SofaResponse) Reset() {
+	s.IsError = false
+	s.ErrorMsg = ""
+	s.AppResponse = nil
+	s.ResponseProps = nil
+}
+
+// SetSofaRequest encodes sr to the content and sets the class, codec and
+// the routing headers of SOFARPC.
+func (c *Request) SetSofaRequest(sr *SofaRequest) error {
+	sigs := sr.MethodArgSigs
+	if len(sigs) == 0 {
+		// Java side expects an array even if the method takes no args.
+		sigs = make([]string, len(sr.Args))
+		for i := range sr.Args {
+			sigs[i] = javaTypeOf(sr.Args[i])
+		}
+	}
+
+	if len(sigs) != len(sr.Args) {
+		return ErrSofaArgSigsMismatch
+	}
+
+	var err error
+
+	ectx := sofahessian.AcquireHessianEncodeContext().SetVersion(sofahessian.Hessian3xV2)
+	c.command.content, err = sofahessian.EncodeToHessian3V2(ectx, c.command.content[:0],
+		&javaobject.SofaRPCRequest{
+			TargetAppName:           sr.TargetAppName,
+			TargetServiceUniqueName: sr.TargetServiceUniqueName,
+			MethodName:              sr.MethodName,
+			MethodArgSigs:           sigs,
+			RequestProps:            sr.RequestProps,
+		})
+	for i := 0; err == nil && i < len(sr.Args); i++ {
+		c.command.content, err = sofahessian.EncodeToHessian3V2(ectx, c.command.content, sr.Args[i])
+	}
+	sofahessian.ReleaseHessianEncodeContext(ectx)
+	if err != nil {
+		return err
+	}
+
+	c.SetClassString(ClassRequest).SetCodec(CodecHessian2)
+	c.setSofaHeaders(sr.TargetAppName, sr.TargetServiceUniqueName, sr.MethodName)
+
+	return nil
+}
+
+// setSofaHeaders sets the headers which SOFARPC routes the request by.
+func (c *Request) setSofaHeaders(app, service, method string) {
+	headers := c.GetRPCHeaders().SetTargetService(service).SetMethodName(method)
+	if app != "" {
+		headers.SetTargetApp(app)
+	}
+}
+
+// GetSofaRequest decodes the content to sr.
+func (c *Request) GetSofaRequest(sr *SofaRequest) error {
+	if string(c.GetClass()) != ClassRequest {
+		return ErrSofaRequestMismatchClass
+	}
+
+	if c.GetCodec() != CodecHessian2 {
+		return ErrSofaMismatchCodec
+	}
+
+	var req javaobject.SofaRPCRequest
+
+	dctx := sofahessian.AcquireHessianDecodeContext().
+		SetVersion(sofahessian.Hessian3xV2).
+		SetClassRegistry(&sofaregistry)
+	bbr := sofahessian.AcquireBytesBufioReader(c.GetContent())
+	br := bbr.GetBufioReader()
+	err := sofahessian.DecodeObjectToHessian3V2(dctx, br, &req)
+
+	sr.Reset()
+	sr.TargetAppName = req.TargetAppName
+	sr.TargetServiceUniqueName = req.TargetServiceUniqueName
+	sr.MethodName = req.MethodName
+	sr.MethodArgSigs = append(sr.MethodArgSigs, req.MethodArgSigs...)
+	sr.RequestProps = req.RequestProps
+
+	var arg interface{}
+	for i := 0; err == nil && i < len(req.MethodArgSigs); i++ {
+		arg, err = sofahessian.DecodeHessian3V2(dctx, br)
+		sr.Args = append(sr.Args, arg)
+	}
+
+	sofahessian.ReleaseBytesBufioReader(bbr)
+	sofahessian.ReleaseHessianDecodeContext(dctx)
+
+	return err
+}
+
+// SetSofaResponse encodes sr to the content and sets the class and codec.
+func (c *Response) SetSofaResponse(sr *SofaResponse) error {
+	var err error
+
+	ectx := sofahessian.AcquireHessianEncodeContext().SetVersion(sofahessian.Hessian3xV2)
+	c.command.content, err = sofahessian.EncodeToHessian3V2(ectx, c.command.content[:0],
+		&javaobject.SofaRPCResponse{
+			IsError:       sr.IsError,
+			ErrorMsg:      sr.ErrorMsg,
+			AppResponse:   sr.AppResponse,
+			ResponseProps: sr.ResponseProps,
+		})
+	sofahessian.ReleaseHessianEncodeContext(ectx)
+	if err != nil {
+		return err
+	}
+
+	c.SetClassString(ClassResponse).SetCodec(CodecHessian2)
+	return nil
+}
+
+// GetSofaResponse decodes the content to sr. A response with IsError set is
+// not treated as a decode error, callers should check sr.IsError.
+func (c *Response) GetSofaResponse(sr *SofaResponse) error {
+	if string(c.GetClass()) != ClassResponse {
+		return ErrSofaResponseMismatchClass
+	}
+
+	if c.GetCodec() != CodecHessian2 {
+		return ErrSofaMismatchCodec
+	}
+
+	var res javaobject.SofaRPCResponse
+
+	dctx := sofahessian.AcquireHessianDecodeContext().
+		SetVersion(sofahessian.Hessian3xV2).
+		SetClassRegistry(&sofaregistry)
+	bbr := sofahessian.AcquireBytesBufioReader(c.GetContent())
+	err := sofahessian.DecodeObjectToHessian3V2(dctx, bbr.GetBufioReader(), &res)
+	sofahessian.ReleaseBytesBufioReader(bbr)
+	sofahessian.ReleaseHessianDecodeContext(dctx)
+	if err != nil {
+		return err
+	}
+
+	sr.IsError = res.IsError
+	sr.ErrorMsg = res.ErrorMsg
+	sr.AppResponse = res.AppResponse
+	sr.ResponseProps = res.ResponseProps
+
+	return nil
+}
+
+// javaTypeOf returns the java type used in method arg sigs for v.
+func javaTypeOf(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "java.lang.Object"
+	case sofahessian.JavaClassNameGetter:
+		return x.GetJavaClassName()
+	case string:
+		return "java.lang.String"
+	case bool:
+		return "boolean"
+	case int8:
+		return "byte"
+	case int16:
+		return "short"
+	case int32:
+		return "int"
+	case int, int64, uint32:
+		return "long"
+	case float32:
+		return "float"
+	case float64:
+		return "double"
+	case []byte:
+		return "[B"
+	case time.Time:
+		return "java.util.Date"
+	}
+
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.Slice, reflect.Array:
+		return "java.util.List"
+	case reflect.Map:
+		return "java.util.Map"
+	}
+
+	return "java.lang.Object"
+}
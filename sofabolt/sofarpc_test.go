@@ -0,0 +1,99 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sofaTestUser struct {
+	Name string `hessian:"name"`
+	Age  int32  `hessian:"age"`
+}
+
+func (u *sofaTestUser) GetJavaClassName() string { return "com.alipay.test.User" }
+
+func init() {
+	if err := RegisterSofaClass(&sofaTestUser{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestSofaRequest(t *testing.T) {
+	var req Request
+	err := req.SetSofaRequest(&SofaRequest{
+		TargetAppName:           "testapp",
+		TargetServiceUniqueName: "com.alipay.test.UserService:1.0",
+		MethodName:              "save",
+		Args:                    []interface{}{"hello", int64(1), &sofaTestUser{Name: "foo", Age: 10}},
+		RequestProps:            map[string]interface{}{"protocol": "bolt"},
+	})
+	require.Nil(t, err)
+	require.Equal(t, ClassRequest, string(req.GetClass()))
+	require.Equal(t, CodecHessian2, req.GetCodec())
+	require.Equal(t, "com.alipay.test.UserService:1.0", req.GetHeaders().Get("service"))
+	require.Equal(t, "com.alipay.test.UserService:1.0", req.GetHeaders().Get(headerTargetService))
+	require.Equal(t, "save", req.GetHeaders().Get(headerMethodName))
+	require.Equal(t, "testapp", req.GetHeaders().Get(headerTargetApp))
+
+	var sr SofaRequest
+	require.Nil(t, req.GetSofaRequest(&sr))
+	require.Equal(t, "testapp", sr.TargetAppName)
+	require.Equal(t, "com.alipay.test.UserService:1.0", sr.TargetServiceUniqueName)
+	require.Equal(t, "save", sr.MethodName)
+	require.Equal(t, []string{"java.lang.String", "long", "com.alipay.test.User"}, sr.MethodArgSigs)
+	require.Equal(t, "bolt", sr.RequestProps["protocol"])
+	require.Len(t, sr.Args, 3)
+	require.Equal(t, "hello", sr.Args[0])
+	require.Equal(t, int64(1), sr.Args[1])
+	require.Equal(t, &sofaTestUser{Name: "foo", Age: 10}, sr.Args[2])
+
+	require.Equal(t, ErrSofaArgSigsMismatch, req.SetSofaRequest(&SofaRequest{
+		MethodArgSigs: []string{"long"},
+		Args:          []interface{}{int64(1), int64(2)},
+	}))
+
+	req.SetClassString("foo")
+	require.Equal(t, ErrSofaRequestMismatchClass, req.GetSofaRequest(&sr))
+}
+
+func TestSofaResponse(t *testing.T) {
+	var res Response
+	require.Nil(t, res.SetSofaResponse(&SofaResponse{
+		AppResponse:   &sofaTestUser{Name: "bar", Age: 20},
+		ResponseProps: map[string]string{"k": "v"},
+	}))
+	require.Equal(t, ClassResponse, string(res.GetClass()))
+	require.Equal(t, CodecHessian2, res.GetCodec())
+
+	var sr SofaResponse
+	require.Nil(t, res.GetSofaResponse(&sr))
+	require.False(t, sr.IsError)
+	require.Equal(t, &sofaTestUser{Name: "bar", Age: 20}, sr.AppResponse)
+	require.Equal(t, map[string]string{"k": "v"}, sr.ResponseProps)
+
+	require.Nil(t, res.SetSofaResponse(&SofaResponse{
+		IsError:  true,
+		ErrorMsg: "boom",
+	}))
+	require.Nil(t, res.GetSofaResponse(&sr))
+	require.True(t, sr.IsError)
+	require.Equal(t, "boom", sr.ErrorMsg)
+	require.Nil(t, sr.AppResponse)
+
+	res.SetCodec(CodecProtobuf)
+	require.Equal(t, ErrSofaMismatchCodec, res.GetSofaResponse(&sr))
+}
@@ -70,6 +70,9 @@ func AcquireInvokeContext(req *Request, res *Response, timeout time.Duration) *I
 	ictx.res = res
 	ictx.created = time.Now()
 	ictx.timeout = timeout
+	ictx.sent = 0
+	ictx.sending = 0
+	ictx.size = 0
 
 	return ictx
 }
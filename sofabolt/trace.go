@@ -0,0 +1,31 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package sofabolt
+
+// ClientTracer traces the calls of a client, e.g. starts a span and injects its
+// context to the headers of the request.
+type ClientTracer interface {
+	// TraceClient is called before req is written. The returned function is called
+	// once the call is done, res is nil if err is not nil.
+	TraceClient(c *Client, req *Request) func(res *Response, err error)
+}
+
+// ServerTracer traces the requests served by a server, e.g. starts a span from
+// the headers of the request.
+type ServerTracer interface {
+	// TraceServer is called before req is handled and may replace the context of
+	// req, which is passed to the services. The returned function is called once
+	// the handler returned.
+	TraceServer(rw ResponseWriter, req *Request) func()
+}
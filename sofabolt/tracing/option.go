@@ -0,0 +1,44 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package tracing
+
+// OptionSetter configures a Tracer.
+type OptionSetter interface {
+	Set(*Tracer)
+}
+
+type OptionSetterFunc func(*Tracer)
+
+func (f OptionSetterFunc) Set(t *Tracer) {
+	f(t)
+}
+
+// WithAppName sets the app name which is propagated as sofaCallerApp.
+func WithAppName(app string) OptionSetterFunc {
+	return OptionSetterFunc(func(t *Tracer) {
+		t.options.app = app
+	})
+}
+
+func WithReporter(r Reporter) OptionSetterFunc {
+	return OptionSetterFunc(func(t *Tracer) {
+		t.options.reporter = r
+	})
+}
+
+func WithTraceIDGenerator(g TraceIDGenerator) OptionSetterFunc {
+	return OptionSetterFunc(func(t *Tracer) {
+		t.options.generator = g
+	})
+}
@@ -0,0 +1,143 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package tracing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt"
+)
+
+type SpanKind uint8
+
+const (
+	SpanKindClient SpanKind = 1
+	SpanKindServer SpanKind = 2
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindClient:
+		return "client"
+	case SpanKindServer:
+		return "server"
+	default:
+		return "unknown"
+	}
+}
+
+// Span records a single BOLT call either sent by a client or handled by
+// a server.
+type Span struct {
+	sync.Mutex
+	tracer     *Tracer
+	kind       SpanKind
+	context    SpanContext
+	children   int32
+	service    string
+	method     string
+	proto      sofabolt.Proto
+	status     sofabolt.Status
+	peer       string
+	err        error
+	start      time.Time
+	duration   time.Duration
+	attributes map[string]string
+	finished   int32
+}
+
+func (s *Span) GetKind() SpanKind                  { return s.kind }
+func (s *Span) GetContext() SpanContext            { return s.context }
+func (s *Span) GetTraceID() string                 { return s.context.TraceID }
+func (s *Span) GetRPCID() string                   { return s.context.RPCID }
+func (s *Span) GetParentRPCID() string             { return ParentRPCID(s.context.RPCID) }
+func (s *Span) GetCallerApp() string               { return s.context.CallerApp }
+func (s *Span) GetService() string                 { return s.service }
+func (s *Span) GetMethod() string                  { return s.method }
+func (s *Span) GetProto() sofabolt.Proto           { return s.proto }
+func (s *Span) GetStatus() sofabolt.Status         { return s.status }
+func (s *Span) GetPeer() string                    { return s.peer }
+func (s *Span) GetError() error                    { return s.err }
+func (s *Span) GetStart() time.Time                { return s.start }
+func (s *Span) GetDuration() time.Duration         { return s.duration }
+func (s *Span) SetPeer(peer string) *Span          { s.peer = peer; return s }
+func (s *Span) SetStatus(st sofabolt.Status) *Span { s.status = st; return s }
+
+// SetAttribute attaches a custom key value pair to the span.
+func (s *Span) SetAttribute(k, v string) *Span {
+	s.Lock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]string)
+	}
+	s.attributes[k] = v
+	s.Unlock()
+	return s
+}
+
+// GetAttribute returns the custom value of the key.
+func (s *Span) GetAttribute(k string) string {
+	s.Lock()
+	v := s.attributes[k]
+	s.Unlock()
+	return v
+}
+
+// RangeAttributes calls fn for each custom attribute.
+func (s *Span) RangeAttributes(fn func(k, v string)) {
+	s.Lock()
+	for k, v := range s.attributes {
+		fn(k, v)
+	}
+	s.Unlock()
+}
+
+// NextChildContext allocates the span context of the next child call.
+func (s *Span) NextChildContext() SpanContext {
+	n := atomic.AddInt32(&s.children, 1)
+	return SpanContext{
+		TraceID: s.context.TraceID,
+		RPCID:   ChildRPCID(s.context.RPCID, int(n)),
+	}
+}
+
+// Finish ends the span and reports it. Calling Finish more than once is a noop.
+func (s *Span) Finish(err error) {
+	if !atomic.CompareAndSwapInt32(&s.finished, 0, 1) {
+		return
+	}
+	s.err = err
+	s.duration = time.Since(s.start)
+	if s.tracer != nil && s.tracer.options.reporter != nil {
+		s.tracer.options.reporter.Report(s)
+	}
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying the span.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, s)
+}
+
+// SpanFromContext returns the span carried by ctx.
+func SpanFromContext(ctx context.Context) (*Span, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	s, ok := ctx.Value(spanContextKey{}).(*Span)
+	return s, ok
+}
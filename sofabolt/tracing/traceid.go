@@ -0,0 +1,79 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package tracing
+
+import (
+	"encoding/hex"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	traceIDSeqMin = 1000
+	traceIDSeqMax = 9000
+)
+
+// TraceIDGenerator generates the id of a new trace.
+type TraceIDGenerator interface {
+	Generate() string
+}
+
+type TraceIDGeneratorFunc func() string
+
+func (f TraceIDGeneratorFunc) Generate() string { return f() }
+
+type sofaTraceIDGenerator struct {
+	prefix string
+	suffix string
+	seq    uint32
+}
+
+// NewTraceIDGenerator returns a generator of SOFATracer style trace ids:
+// hex encoded IPv4, unix milliseconds, a 4 digits sequence and the pid.
+func NewTraceIDGenerator() TraceIDGenerator {
+	return &sofaTraceIDGenerator{
+		prefix: hex.EncodeToString(localIPv4()),
+		suffix: strconv.Itoa(os.Getpid()),
+	}
+}
+
+func (g *sofaTraceIDGenerator) Generate() string {
+	seq := traceIDSeqMin + atomic.AddUint32(&g.seq, 1)%(traceIDSeqMax-traceIDSeqMin+1)
+
+	b := make([]byte, 0, len(g.prefix)+13+4+len(g.suffix))
+	b = append(b, g.prefix...)
+	b = strconv.AppendInt(b, time.Now().UnixNano()/int64(time.Millisecond), 10)
+	b = strconv.AppendUint(b, uint64(seq), 10)
+	b = append(b, g.suffix...)
+	return string(b)
+}
+
+func localIPv4() net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err == nil {
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.IsLoopback() {
+				continue
+			}
+			if ip := ipnet.IP.To4(); ip != nil {
+				return ip
+			}
+		}
+	}
+	return net.IPv4(127, 0, 0, 1).To4()
+}
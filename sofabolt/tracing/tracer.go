@@ -0,0 +1,176 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package tracing
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt"
+)
+
+// Doer sends a request and waits the response. *sofabolt.Client,
+// *sofabolt.MultiConnClient and *sofabolt.Retrier implement it.
+type Doer interface {
+	DoTimeout(req *sofabolt.Request, res *sofabolt.Response, timeout time.Duration) error
+}
+
+// HandlerFunc serves a request with the context carrying the server span so
+// that downstream calls made through the Tracer become its children.
+type HandlerFunc func(ctx context.Context, rw sofabolt.ResponseWriter, req *sofabolt.Request)
+
+// Tracer creates client and server spans and propagates the SOFATracer
+// headers between them. It's usually configured by sofabolt.WithClientTracer
+// and sofabolt.WithServerTracer.
+type Tracer struct {
+	options struct {
+		app       string
+		reporter  Reporter
+		generator TraceIDGenerator
+	}
+}
+
+func NewTracer(options ...OptionSetter) *Tracer {
+	t := &Tracer{}
+	for i := range options {
+		options[i].Set(t)
+	}
+	t.polyfill()
+	return t
+}
+
+func (t *Tracer) polyfill() {
+	if t.options.generator == nil {
+		t.options.generator = NewTraceIDGenerator()
+	}
+}
+
+// StartClientSpan starts a client span of req which is the child of the span
+// in ctx or the root of a new trace, and injects its context to req headers.
+func (t *Tracer) StartClientSpan(ctx context.Context, req *sofabolt.Request) *Span {
+	var sc SpanContext
+	if parent, ok := SpanFromContext(ctx); ok {
+		sc = parent.NextChildContext()
+	} else {
+		sc = SpanContext{
+			TraceID: t.options.generator.Generate(),
+			RPCID:   rootRPCID,
+		}
+	}
+	sc.CallerApp = t.options.app
+
+	h := req.GetHeaders()
+	Inject(h, sc)
+
+	return t.newSpan(SpanKindClient, sc, req)
+}
+
+// StartServerSpan starts a server span from the headers of req. A new trace
+// is started if req does not carry a valid context.
+func (t *Tracer) StartServerSpan(req *sofabolt.Request) *Span {
+	sc := Extract(req.GetHeaders())
+	if !sc.IsValid() {
+		sc.TraceID = t.options.generator.Generate()
+		sc.RPCID = rootRPCID
+	}
+	return t.newSpan(SpanKindServer, sc, req)
+}
+
+func (t *Tracer) newSpan(kind SpanKind, sc SpanContext, req *sofabolt.Request) *Span {
+	r := req.GetRPCHeaders()
+	return &Span{
+		tracer:  t,
+		kind:    kind,
+		context: sc,
+		service: copyString(r.GetTargetService()),
+		method:  copyString(r.GetMethodName()),
+		proto:   req.GetProto(),
+		start:   time.Now(),
+	}
+}
+
+// TraceClient implements sofabolt.ClientTracer: it starts a client span which
+// is the child of the span in the context of req.
+func (t *Tracer) TraceClient(c *sofabolt.Client, req *sofabolt.Request) func(res *sofabolt.Response, err error) {
+	span := t.StartClientSpan(req.GetContext(), req)
+	span.SetPeer(remoteAddr(c.GetConn()))
+
+	return func(res *sofabolt.Response, err error) {
+		if err == nil && res != nil {
+			span.SetStatus(res.GetStatus())
+		}
+		span.Finish(err)
+	}
+}
+
+// TraceServer implements sofabolt.ServerTracer: it starts a server span and
+// carries it by the context of req, so the calls made with the context by the
+// services or the handler become its children.
+func (t *Tracer) TraceServer(rw sofabolt.ResponseWriter, req *sofabolt.Request) func() {
+	span := t.StartServerSpan(req)
+	span.SetPeer(remoteAddr(rw.GetConn()))
+	req.SetContext(ContextWithSpan(req.GetContext(), span))
+
+	return func() {
+		span.SetStatus(rw.GetResponse().GetStatus())
+		span.Finish(rw.GetWriteError())
+	}
+}
+
+func (t *Tracer) Do(ctx context.Context, d Doer, req *sofabolt.Request, res *sofabolt.Response) error {
+	return t.DoTimeout(ctx, d, req, res, 0)
+}
+
+// DoTimeout sends req through d within a client span. It helps the Doers other
+// than a Client configured by sofabolt.WithClientTracer, which traces by itself.
+func (t *Tracer) DoTimeout(ctx context.Context, d Doer, req *sofabolt.Request,
+	res *sofabolt.Response, timeout time.Duration) error {
+	span := t.StartClientSpan(ctx, req)
+	if cg, ok := d.(interface{ GetConn() net.Conn }); ok {
+		span.SetPeer(remoteAddr(cg.GetConn()))
+	}
+
+	err := d.DoTimeout(req, res, timeout)
+	if err == nil {
+		span.SetStatus(res.GetStatus())
+	}
+	span.Finish(err)
+
+	return err
+}
+
+// Handler returns a sofabolt.Handler which serves every request within a
+// server span. It helps the servers not configured by sofabolt.WithServerTracer.
+func (t *Tracer) Handler(fn HandlerFunc) sofabolt.Handler {
+	return sofabolt.HandlerFunc(func(rw sofabolt.ResponseWriter, req *sofabolt.Request) {
+		finish := t.TraceServer(rw, req)
+		fn(req.GetContext(), rw, req)
+		finish()
+	})
+}
+
+// WrapHandler wraps h which has no interest in the server span.
+func (t *Tracer) WrapHandler(h sofabolt.Handler) sofabolt.Handler {
+	return t.Handler(func(ctx context.Context, rw sofabolt.ResponseWriter, req *sofabolt.Request) {
+		h.ServeSofaBOLT(rw, req)
+	})
+}
+
+func remoteAddr(conn net.Conn) string {
+	if conn == nil || conn.RemoteAddr() == nil {
+		return ""
+	}
+	return conn.RemoteAddr().String()
+}
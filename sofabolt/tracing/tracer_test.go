@@ -0,0 +1,202 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+package tracing
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt"
+	"github.com/stretchr/testify/require"
+)
+
+type spanRecorder struct {
+	sync.Mutex
+	spans []*Span
+}
+
+func (r *spanRecorder) Report(s *Span) {
+	r.Lock()
+	r.spans = append(r.spans, s)
+	r.Unlock()
+}
+
+func (r *spanRecorder) get() []*Span {
+	r.Lock()
+	defer r.Unlock()
+	return append([]*Span(nil), r.spans...)
+}
+
+func newTestClient(t *testing.T, h sofabolt.Handler) *sofabolt.Client {
+	srv, err := sofabolt.NewServer(sofabolt.WithServerHandler(h))
+	require.Nil(t, err)
+
+	p0, p1 := net.Pipe()
+	go srv.ServeConn(p1) // nolint
+
+	c, err := sofabolt.NewClient(sofabolt.WithClientConn(p0))
+	require.Nil(t, err)
+	return c
+}
+
+func TestRPCID(t *testing.T) {
+	require.Equal(t, "0.1", ChildRPCID("0", 1))
+	require.Equal(t, "0.1.2", ChildRPCID("0.1", 2))
+	require.Equal(t, "0.1", ParentRPCID("0.1.2"))
+	require.Equal(t, "", ParentRPCID("0"))
+}
+
+func TestInjectExtract(t *testing.T) {
+	var h sofabolt.SimpleMap
+	sc := SpanContext{TraceID: "0a0000011600000000001000123", RPCID: "0.1", CallerApp: "app"}
+	Inject(&h, sc)
+	require.Equal(t, "0.1", h.Get(sofabolt.HeaderRPCID))
+	require.Equal(t, sc, Extract(&h))
+	require.True(t, sc.IsValid())
+}
+
+func TestTraceIDGenerator(t *testing.T) {
+	g := NewTraceIDGenerator()
+	a, b := g.Generate(), g.Generate()
+	require.NotEqual(t, a, b)
+	require.Len(t, a[:8+13+4], 25)
+}
+
+func TestTracerPropagation(t *testing.T) {
+	recorder := &spanRecorder{}
+	backend := NewTracer(WithAppName("backend"), WithReporter(recorder))
+	frontend := NewTracer(WithAppName("frontend"), WithReporter(recorder))
+
+	leaf := newTestClient(t, backend.WrapHandler(sofabolt.HandlerFunc(
+		func(rw sofabolt.ResponseWriter, req *sofabolt.Request) {
+			rw.GetResponse().SetStatus(sofabolt.StatusSuccess)
+			rw.Write() // nolint
+		})))
+	defer leaf.Close()
+
+	mid := newTestClient(t, backend.Handler(
+		func(ctx context.Context, rw sofabolt.ResponseWriter, req *sofabolt.Request) {
+			for i := 0; i < 2; i++ {
+				creq := sofabolt.AcquireRequest()
+				cres := sofabolt.AcquireResponse()
+				require.Nil(t, backend.DoTimeout(ctx, leaf, creq, cres, time.Second))
+				sofabolt.ReleaseRequest(creq)
+				sofabolt.ReleaseResponse(cres)
+			}
+			rw.Write() // nolint
+		}))
+	defer mid.Close()
+
+	req := sofabolt.AcquireRequest()
+	req.GetRPCHeaders().SetTargetService("com.alipay.test.Service:1.0")
+	res := sofabolt.AcquireResponse()
+	require.Nil(t, frontend.Do(context.Background(), mid, req, res))
+
+	// server spans finish after the response has been written
+	require.Eventually(t, func() bool { return len(recorder.get()) == 6 }, time.Second, time.Millisecond)
+	spans := recorder.get()
+
+	byRPCID := make(map[string][]*Span)
+	for _, s := range spans {
+		require.Equal(t, spans[0].GetTraceID(), s.GetTraceID())
+		byRPCID[s.GetRPCID()] = append(byRPCID[s.GetRPCID()], s)
+	}
+	require.Len(t, byRPCID["0"], 2)
+	require.Len(t, byRPCID["0.1"], 2)
+	require.Len(t, byRPCID["0.2"], 2)
+
+	for _, s := range byRPCID["0"] {
+		if s.GetKind() == SpanKindServer {
+			require.Equal(t, "frontend", s.GetCallerApp())
+			require.Equal(t, "com.alipay.test.Service:1.0", s.GetService())
+		} else {
+			require.Equal(t, "pipe", s.GetPeer())
+			require.Equal(t, sofabolt.ProtoBOLTV1, s.GetProto())
+		}
+	}
+	for _, s := range byRPCID["0.1"] {
+		require.Equal(t, "backend", s.GetCallerApp())
+		require.Equal(t, "0", s.GetParentRPCID())
+		require.Equal(t, sofabolt.StatusSuccess, s.GetStatus())
+	}
+}
+
+func TestTracerOptions(t *testing.T) {
+	recorder := &spanRecorder{}
+	backend := NewTracer(WithAppName("backend"), WithReporter(recorder))
+	frontend := NewTracer(WithAppName("frontend"), WithReporter(recorder))
+
+	newClient := func(h sofabolt.Handler, client, server *Tracer) *sofabolt.Client {
+		srv, err := sofabolt.NewServer(sofabolt.WithServerHandler(h), sofabolt.WithServerTracer(server))
+		require.Nil(t, err)
+
+		p0, p1 := net.Pipe()
+		go srv.ServeConn(p1) // nolint
+
+		c, err := sofabolt.NewClient(sofabolt.WithClientConn(p0), sofabolt.WithClientTracer(client))
+		require.Nil(t, err)
+		return c
+	}
+
+	leaf := newClient(sofabolt.HandlerFunc(func(rw sofabolt.ResponseWriter, req *sofabolt.Request) {
+		rw.Write() // nolint
+	}), backend, backend)
+	defer leaf.Close()
+
+	mid := newClient(sofabolt.HandlerFunc(func(rw sofabolt.ResponseWriter, req *sofabolt.Request) {
+		creq := sofabolt.AcquireRequest()
+		cres := sofabolt.AcquireResponse()
+		creq.SetContext(req.GetContext())
+		require.Nil(t, leaf.DoTimeout(creq, cres, time.Second))
+		sofabolt.ReleaseRequest(creq)
+		sofabolt.ReleaseResponse(cres)
+
+		creq = sofabolt.AcquireRequest()
+		creq.SetContext(req.GetContext())
+		done := make(chan struct{})
+		require.Nil(t, leaf.DoCallbackTimeout(creq, sofabolt.ClientCallbackerFunc(
+			func(err error, ictx *sofabolt.InvokeContext) {
+				require.Nil(t, err)
+				close(done)
+			}), time.Second))
+		<-done
+		sofabolt.ReleaseRequest(creq)
+
+		rw.Write() // nolint
+	}), frontend, backend)
+	defer mid.Close()
+
+	req := sofabolt.AcquireRequest()
+	res := sofabolt.AcquireResponse()
+	require.Nil(t, mid.DoTimeout(req, res, time.Second))
+
+	require.Eventually(t, func() bool { return len(recorder.get()) == 6 }, time.Second, time.Millisecond)
+	spans := recorder.get()
+
+	byRPCID := make(map[string][]*Span)
+	for _, s := range spans {
+		require.Equal(t, spans[0].GetTraceID(), s.GetTraceID())
+		byRPCID[s.GetRPCID()] = append(byRPCID[s.GetRPCID()], s)
+	}
+	require.Len(t, byRPCID["0"], 2)
+	require.Len(t, byRPCID["0.1"], 2)
+	require.Len(t, byRPCID["0.2"], 2)
+	for _, s := range byRPCID["0.2"] {
+		require.Equal(t, "backend", s.GetCallerApp())
+		require.Equal(t, sofabolt.StatusSuccess, s.GetStatus())
+	}
+}
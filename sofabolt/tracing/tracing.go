@@ -0,0 +1,89 @@
+// nolint
+// Copyright 20xx The Alipay Authors.
+//
+// @authors[0]: bingwu.ybw(bingwu.ybw@antfin.com|detailyang@gmail.com)
+// @authors[1]: robotx(robotx@antfin.com)
+//
+// *Legal Disclaimer*
+// Within this source code, the comments in Chinese shall be the original, governing version. Any comment in other languages are for reference only. In the event of any conflict between the Chinese language version comments and other language version comments, the Chinese language version shall prevail.
+// *法律免责声明*
+// 关于代码注释部分，中文注释为官方版本，其它语言注释仅做参考。中文注释可能与其它语言注释存在不一致，当中文注释与其它语言注释存在不一致时，请以中文注释为准。
+//
+//
+
+// Package tracing propagates SOFATracer compatible trace context over BOLT
+// requests and reports client and server spans.
+package tracing
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sofastack/sofa-bolt-go/sofabolt"
+)
+
+const rootRPCID = "0"
+
+// SpanContext is the trace context carried by the rpc_trace_context headers.
+type SpanContext struct {
+	TraceID   string
+	RPCID     string
+	CallerApp string
+}
+
+// IsValid reports whether the context has both trace id and rpc id.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.RPCID != ""
+}
+
+// Extract reads the span context from headers. The values are copied since
+// the headers are usually recycled once the request was served.
+func Extract(h *sofabolt.SimpleMap) SpanContext {
+	r := sofabolt.NewRPCHeaders(h)
+	return SpanContext{
+		TraceID:   copyString(r.GetTraceID()),
+		RPCID:     copyString(r.GetRPCID()),
+		CallerApp: copyString(r.GetCallerApp()),
+	}
+}
+
+// Inject writes the span context to headers. Empty fields are left untouched.
+func Inject(h *sofabolt.SimpleMap, sc SpanContext) {
+	r := sofabolt.NewRPCHeaders(h)
+	if sc.TraceID != "" {
+		r.SetTraceID(sc.TraceID)
+	}
+	if sc.RPCID != "" {
+		r.SetRPCID(sc.RPCID)
+	}
+	if sc.CallerApp != "" {
+		r.SetCallerApp(sc.CallerApp)
+	}
+}
+
+// ChildRPCID returns the rpc id of the nth (1-based) child call, e.g. the
+// second child of "0.1" is "0.1.2".
+func ChildRPCID(parent string, n int) string {
+	if parent == "" {
+		parent = rootRPCID
+	}
+	return parent + "." + strconv.Itoa(n)
+}
+
+// ParentRPCID returns the rpc id of the parent or empty for the root.
+func ParentRPCID(rpcid string) string {
+	i := strings.LastIndexByte(rpcid, '.')
+	if i < 0 {
+		return ""
+	}
+	return rpcid[:i]
+}
+
+func copyString(v string) string {
+	if v == "" {
+		return ""
+	}
+	b := make([]byte, len(v))
+	copy(b, v)
+	return string(b)
+}